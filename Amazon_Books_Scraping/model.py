@@ -1,3 +1,4 @@
+import os
 import pandas as pd
 import numpy as np
 from fuzzywuzzy import process
@@ -14,6 +15,8 @@ from collections import defaultdict
 class ServiceAPI:
     def __init__(self, base_url: str):
         self.base_url = base_url 
+        # The Go service only accepts writes carrying the shared SERVICE_TOKEN
+        self.headers = {"X-Service-Token": os.environ.get("SERVICE_TOKEN", "")}
     def get_user_preferences(self, user_id: int) -> Dict[str, Any]:
         response = requests.get(f"{self.base_url}/user/{user_id}/preferences")
         try:
@@ -32,7 +35,7 @@ class ServiceAPI:
         
     def update_user_preferences(self, user_id: int, preferences: dict):
         try:
-            response = requests.post(f"{self.base_url}/user/{user_id}/preferences", json=preferences)
+            response = requests.post(f"{self.base_url}/user/{user_id}/preferences", json=preferences, headers=self.headers)
             if response.status_code != 200:
                 print(f"Error updating user preferences: {response.status_code}")
         except Exception as e:
@@ -40,7 +43,7 @@ class ServiceAPI:
 
     def update_global_preferences(self, preferences: dict):
         try:
-            response = requests.post(f"{self.base_url}/global/preferences", json=preferences)
+            response = requests.post(f"{self.base_url}/global/preferences", json=preferences, headers=self.headers)
             if response.status_code != 200:
                 print(f"Error updating user preferences: {response.status_code}")
         except Exception as e:
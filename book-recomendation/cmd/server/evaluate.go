@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"book-recommendation-service/pkg/config"
+	"book-recommendation-service/pkg/db"
+	"book-recommendation-service/pkg/evaluation"
+	"book-recommendation-service/pkg/models"
+	"book-recommendation-service/pkg/services"
+)
+
+// runEvaluate implements `book-recomendation evaluate`: it scores every
+// recommender offline, on synthetic interactions over Books_df.csv by
+// default, or on the database's books and interactions
+func runEvaluate(args []string) error {
+	flags := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	source := flags.String("source", "fixture", "Data to evaluate on (fixture|db)")
+	booksPath := flags.String("books", "../Amazon_Books_Scraping/Books_df.csv", "Books CSV of the fixture")
+	users := flags.Int("users", 500, "Number of synthetic users of the fixture")
+	seed := flags.Int64("seed", 1, "Seed of the fixture")
+	k := flags.Int("k", services.DefaultRecommendationCount, "Number of recommendations scored per user")
+	split := flags.Float64("split", 0.8, "Share of interactions, oldest first, used for training")
+	format := flags.String("format", "table", "Output format (table|json)")
+	flags.Parse(args)
+
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	cfg := config.LoadConfig()
+
+	var books []models.Book
+	var interactions []models.BookInteraction
+
+	switch *source {
+	case "fixture":
+		var err error
+		books, err = evaluation.LoadBooksCSV(*booksPath)
+		if err != nil {
+			return err
+		}
+		interactions = evaluation.SyntheticInteractions(books, evaluation.FixtureOptions{
+			Users: *users,
+			Seed:  *seed,
+			Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Span:  180 * 24 * time.Hour,
+		})
+	case "db":
+		repo, err := db.NewRepository(cfg.DB)
+		if err != nil {
+			return fmt.Errorf("connecting to database: %v", err)
+		}
+		defer repo.Close()
+
+		if books, err = repo.GetAllBooks(); err != nil {
+			return err
+		}
+		if interactions, err = repo.GetAllInteractions(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown source %q", *source)
+	}
+
+	report, err := evaluation.Run(context.Background(), books, interactions, evaluation.Options{
+		K:             *k,
+		TrainFraction: *split,
+		HalfLife:      cfg.PreferenceHalfLife,
+		Weights:       hybridWeights(cfg),
+	})
+	if err != nil {
+		return err
+	}
+	if *source == "fixture" {
+		report.Notes = append(report.Notes, evaluation.FixtureBias)
+	}
+
+	if *format == "json" {
+		return evaluation.WriteJSON(os.Stdout, report)
+	}
+	return evaluation.WriteTable(os.Stdout, report)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
 
 	"book-recommendation-service/pkg/api"
 	"book-recommendation-service/pkg/config"
@@ -11,6 +12,12 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "evaluate" {
+		if err := runEvaluate(os.Args[2:]); err != nil {
+			log.Fatalf("Evaluation failed: %v", err)
+		}
+		return
+	}
 
 	cfg := config.LoadConfig()
 
@@ -20,12 +27,97 @@ func main() {
 	}
 	defer repo.Close()
 
-	recService := services.NewRecommendationService(cfg.FriendServiceURL)
+	catalog := services.NewCatalog(repo.GetAllBooks)
+	if err := catalog.Refresh(); err != nil {
+		log.Fatalf("Failed to load book catalog: %v", err)
+	}
+	catalog.StartRefresh(cfg.CatalogRefresh)
 
-	handler := api.NewHandler(repo, recService)
+	cf := services.NewCollaborativeRecommender(catalog, repo.GetAllInteractions)
+	if err := cf.Rebuild(); err != nil {
+		log.Printf("Failed to build collaborative model, starting empty: %v", err)
+	}
+	cf.StartRebuild(cfg.CFRebuild)
 
-	router := api.SetupRoutes(handler)
+	aggregator := services.NewPreferenceAggregator(repo, catalog, cfg.PreferenceHalfLife)
+	if err := aggregator.Run(); err != nil {
+		log.Printf("Failed to learn preferences from interactions: %v", err)
+	}
+	aggregator.Start(cfg.PreferenceAggregate)
+
+	popular := services.NewPopularRecommender(catalog, repo)
+	newRecommender := func(name string, weights services.HybridWeights) (services.Recommender, error) {
+		recService, err := services.NewRecommender(name, catalog, repo, cf, weights, modelServiceOptions(cfg))
+		if err != nil {
+			return nil, err
+		}
+		recService = services.WithPopularFallback(recService, popular)
+		return services.WithMetadata(recService, catalog, repo), nil
+	}
+
+	recService, err := newRecommender(cfg.Recommender, hybridWeights(cfg))
+	if err != nil {
+		log.Fatalf("Failed to create recommender: %v", err)
+	}
+
+	var experiment *services.ExperimentRecommender
+	if cfg.ExperimentFile != "" {
+		experiment, err = newExperiment(cfg, repo, newRecommender)
+		if err != nil {
+			log.Fatalf("Failed to start experiment: %v", err)
+		}
+		recService = experiment
+		log.Printf("Running experiment %q", experiment.Experiment().Name)
+	}
+
+	handler := api.NewHandler(repo, catalog, recService, cf, experiment)
+
+	if cfg.ServiceToken == "" {
+		log.Printf("SERVICE_TOKEN is not set, writes from other services will be refused")
+	}
+	router := api.SetupRoutes(handler, cfg.ServiceToken)
 
 	log.Printf("Starting server on port %s", cfg.Port)
 	log.Fatal(http.ListenAndServe(":"+cfg.Port, router))
 }
+
+// newExperiment loads the experiment of EXPERIMENT_FILE and builds the
+// recommender of each variant, defaulting to the configured ones
+func newExperiment(cfg config.Config, store services.ExperimentStore, newRecommender func(string, services.HybridWeights) (services.Recommender, error)) (*services.ExperimentRecommender, error) {
+	experiment, err := services.LoadExperiment(cfg.ExperimentFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return services.NewExperimentRecommender(experiment, store, func(variant services.Variant) (services.Recommender, error) {
+		name, weights := cfg.Recommender, hybridWeights(cfg)
+		if variant.Recommender != "" {
+			name = variant.Recommender
+		}
+		if variant.Weights != nil {
+			weights = *variant.Weights
+		}
+		return newRecommender(name, weights)
+	})
+}
+
+func hybridWeights(cfg config.Config) services.HybridWeights {
+	return services.HybridWeights{
+		Content:       cfg.Weights.Content,
+		Collaborative: cfg.Weights.Collaborative,
+		Popularity:    cfg.Weights.Popularity,
+		Preferences:   cfg.Weights.Preferences,
+	}
+}
+
+func modelServiceOptions(cfg config.Config) services.ModelServiceOptions {
+	return services.ModelServiceOptions{
+		URL:             cfg.FriendServiceURL,
+		Timeout:         cfg.ModelService.Timeout,
+		Retries:         cfg.ModelService.Retries,
+		Backoff:         cfg.ModelService.Backoff,
+		BreakerFailures: cfg.ModelService.BreakerFailures,
+		BreakerCooldown: cfg.ModelService.BreakerCooldown,
+		CacheTTL:        cfg.ModelService.CacheTTL,
+	}
+}
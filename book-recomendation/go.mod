@@ -2,4 +2,4 @@ module book-recommendation-service
 
 go 1.24.2
 
-require github.com/lib/pq v1.10.9 // indirect
+require github.com/lib/pq v1.10.9
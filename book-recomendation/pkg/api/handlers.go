@@ -2,6 +2,8 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
@@ -14,13 +16,20 @@ import (
 
 type Handler struct {
 	repo       *db.Repository
-	recService *services.RecommendationService
+	catalog    *services.Catalog
+	recService services.Recommender
+	cf         *services.CollaborativeRecommender
+	// experiment is the running experiment, nil when there is none
+	experiment *services.ExperimentRecommender
 }
 
-func NewHandler(repo *db.Repository, recService *services.RecommendationService) *Handler {
+func NewHandler(repo *db.Repository, catalog *services.Catalog, recService services.Recommender, cf *services.CollaborativeRecommender, experiment *services.ExperimentRecommender) *Handler {
 	return &Handler{
 		repo:       repo,
+		catalog:    catalog,
 		recService: recService,
+		cf:         cf,
+		experiment: experiment,
 	}
 }
 
@@ -42,7 +51,7 @@ func (h *Handler) HandleRecommendationRequest(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	recommendations, err := h.recService.GetRecommendations(req)
+	recommendations, err := h.recService.GetRecommendations(r.Context(), req)
 	if err != nil {
 		log.Printf("Error getting recommendations: %v", err)
 		http.Error(w, "Failed to get recommendations", http.StatusInternalServerError)
@@ -82,12 +91,22 @@ func (h *Handler) HandleUserPreferencesRequest(w http.ResponseWriter, r *http.Re
 }
 
 func (h *Handler) HandleUsersRequest(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+
+	if len(parts) >= 4 && parts[3] == "onboarding" {
+		h.HandleOnboardingRequest(w, r)
+		return
+	}
+
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) >= 4 && parts[3] == "recommendations" {
+		h.HandleUserRecommendationsRequest(w, r)
+		return
+	}
 
 	if len(parts) <= 2 || parts[2] == "" {
 		users, err := h.repo.GetAllUsers()
@@ -125,6 +144,11 @@ func (h *Handler) HandleUsersRequest(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleGlobalPreferencesRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		h.HandleUpdateGlobalPreferences(w, r)
+		return
+	}
+
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -166,6 +190,11 @@ func (h *Handler) HandleUpdateUserPreferences(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if err := validatePreferences(prefs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if err := h.repo.UpdateUserPreferences(userID, prefs); err != nil {
 		log.Printf("Error updating preferences: %v", err)
 		http.Error(w, "Failed to update preferences", http.StatusInternalServerError)
@@ -175,3 +204,387 @@ func (h *Handler) HandleUpdateUserPreferences(w http.ResponseWriter, r *http.Req
 	w.Header().Set("Content-Type", "application/json")
 	w.Write([]byte(`{"status":"success"}`))
 }
+
+// HandleUpdateGlobalPreferences serves POST /global/preferences, where
+// model.py stores the global averages it computes. Only values without a
+// global weight yet are stored, see db.Repository.UpdateGlobalPreferences.
+func (h *Handler) HandleUpdateGlobalPreferences(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var prefs models.UserPreferencesMap
+	if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+		http.Error(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	if err := validatePreferences(prefs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.UpdateGlobalPreferences(prefs); err != nil {
+		log.Printf("Error updating global preferences: %v", err)
+		http.Error(w, "Failed to update global preferences", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"success"}`))
+}
+
+// validatePreferences accepts the attributes of services.Attributes with
+// non-empty values and non-negative weights
+func validatePreferences(prefs models.UserPreferencesMap) error {
+	for category, values := range prefs {
+		known := false
+		for _, attribute := range services.Attributes {
+			if category == attribute {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown preference category %q", category)
+		}
+
+		for value, weight := range values {
+			if value == "" {
+				return fmt.Errorf("empty %s value", category)
+			}
+			if weight < 0 {
+				return fmt.Errorf("negative weight for %s %q", category, value)
+			}
+		}
+	}
+	return nil
+}
+
+// HandleSimilarBooksRequest serves GET /books/{id}/similar: "users who liked
+// this book also liked"
+func (h *Handler) HandleSimilarBooksRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 3 {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	bookID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		http.Error(w, "Invalid book ID", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := readLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	similar := h.cf.Similar(bookID, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"book_id": bookID, "similar": similar})
+}
+
+// HandleUserRecommendationsRequest serves GET /users/{id}/recommendations, the
+// personalized top-N from the collaborative filter
+func (h *Handler) HandleUserRecommendationsRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 3 {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := readLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recommendations := h.cf.RecommendForUser(userID, nil, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"user_id": userID, "recommendations": recommendations})
+}
+
+// HandleOnboardingGenresRequest serves GET /onboarding/genres, the genres,
+// sub-genres and sample books new users pick from
+func (h *Handler) HandleOnboardingGenresRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit, err := readLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	globalPrefs, err := h.repo.GetGlobalPreferences()
+	if err != nil {
+		log.Printf("Picking onboarding genres without global preferences: %v", err)
+		globalPrefs = models.UserPreferencesMap{}
+	}
+
+	genres := services.OnboardingGenres(h.catalog, globalPrefs, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"genres": genres})
+}
+
+// HandleOnboardingRequest serves POST /users/{id}/onboarding, seeding the
+// user's preferences from their questionnaire. book-service posts it for the
+// signed in user.
+func (h *Handler) HandleOnboardingRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+	userID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var selection models.OnboardingSelection
+	if err := json.NewDecoder(r.Body).Decode(&selection); err != nil {
+		http.Error(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := services.OnboardingPreferences(h.catalog, selection)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.repo.GetUser(userID); err != nil {
+		if err.Error() == "user not found" {
+			http.Error(w, "User not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error getting user: %v", err)
+			http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := h.repo.UpdateUserPreferences(userID, prefs); err != nil {
+		log.Printf("Error seeding preferences: %v", err)
+		http.Error(w, "Failed to save onboarding", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// HandleEventsRequest serves POST /events, storing an interaction the
+// preference aggregator learns from
+func (h *Handler) HandleEventsRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var event models.InteractionEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		http.Error(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	if event.BookID == 0 && event.BookTitle != "" {
+		book, ok := h.catalog.FindByTitle(event.BookTitle)
+		if !ok {
+			http.Error(w, "Unknown book", http.StatusUnprocessableEntity)
+			return
+		}
+		event.BookID = book.ID
+	}
+
+	if err := validateEvent(event); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	interaction := models.BookInteraction{
+		UserID: event.UserID,
+		BookID: event.BookID,
+		Type:   event.Type,
+		Rating: event.Rating,
+	}
+
+	if err := h.repo.InsertInteraction(&interaction); err != nil {
+		if errors.Is(err, db.ErrUnknownUserOrBook) {
+			http.Error(w, "Unknown user or book", http.StatusUnprocessableEntity)
+			return
+		}
+		log.Printf("Error storing interaction: %v", err)
+		http.Error(w, "Failed to store interaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(interaction)
+}
+
+func validateEvent(event models.InteractionEvent) error {
+	if event.UserID <= 0 {
+		return errors.New("user_id must be provided")
+	}
+	if event.BookID <= 0 {
+		return errors.New("book_id or book_title must be provided")
+	}
+
+	known := false
+	for _, t := range services.InteractionTypes {
+		if event.Type == t {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("interaction_type must be one of %s", strings.Join(services.InteractionTypes, ", "))
+	}
+
+	if event.Type == "rate" && (event.Rating < 1 || event.Rating > 5) {
+		return errors.New("rating must be between 1 and 5")
+	}
+	if event.Type != "rate" && event.Rating != 0 {
+		return errors.New("rating is only allowed for rate interactions")
+	}
+
+	return nil
+}
+
+// HandleExperimentsRequest serves GET /experiments, the running experiment,
+// POST /experiments/clicks and GET /experiments/{name}/results
+func (h *Handler) HandleExperimentsRequest(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) == 2:
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if h.experiment == nil {
+			http.Error(w, "No experiment is running", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(h.experiment.Experiment())
+	case len(parts) == 3 && parts[2] == "clicks":
+		h.HandleExperimentClick(w, r)
+	case len(parts) == 4 && parts[3] == "results":
+		h.HandleExperimentResults(w, r, parts[2])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// HandleExperimentClick serves POST /experiments/clicks, logging a click on a
+// recommended book for the user's variant of the running experiment.
+// book-service posts it for the signed in user.
+func (h *Handler) HandleExperimentClick(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.experiment == nil {
+		http.Error(w, "No experiment is running", http.StatusNotFound)
+		return
+	}
+
+	var click models.ExperimentClick
+	if err := json.NewDecoder(r.Body).Decode(&click); err != nil {
+		http.Error(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	if click.UserID <= 0 || click.BookID <= 0 {
+		http.Error(w, "user_id and book_id must be provided", http.StatusBadRequest)
+		return
+	}
+
+	assignment, err := h.experiment.LogClick(click)
+	if err != nil {
+		if errors.Is(err, db.ErrNoImpression) {
+			http.Error(w, "Book was not recommended to the user", http.StatusUnprocessableEntity)
+			return
+		}
+		log.Printf("Error logging click: %v", err)
+		http.Error(w, "Failed to log click", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(assignment)
+}
+
+// HandleExperimentResults serves GET /experiments/{name}/results, the
+// click-through rate of every variant with its 95% confidence interval
+func (h *Handler) HandleExperimentResults(w http.ResponseWriter, r *http.Request, experiment string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	counts, err := h.repo.GetExperimentCounts(experiment)
+	if err != nil {
+		log.Printf("Error getting experiment counts: %v", err)
+		http.Error(w, "Failed to get experiment results", http.StatusInternalServerError)
+		return
+	}
+
+	if len(counts) == 0 {
+		http.Error(w, "Experiment not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"experiment": experiment,
+		"variants":   services.ExperimentResults(counts),
+	})
+}
+
+// readLimit reads the limit query parameter, 10 by default and at most 100
+func readLimit(r *http.Request) (int, error) {
+	s := r.URL.Query().Get("limit")
+	if s == "" {
+		return services.DefaultRecommendationCount, nil
+	}
+
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > 100 {
+		return 0, errors.New("limit must be between 1 and 100")
+	}
+	return limit, nil
+}
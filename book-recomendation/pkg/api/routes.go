@@ -7,12 +7,18 @@ import (
 	"book-recommendation-service/pkg/middleware"
 )
 
-func SetupRoutes(handler *Handler) http.Handler {
+// SetupRoutes registers the endpoints. Writes other services make need
+// serviceToken, see middleware.RequireServiceToken.
+func SetupRoutes(handler *Handler, serviceToken string) http.Handler {
 
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/recommendations", handler.HandleRecommendationRequest)
-	mux.HandleFunc("/global/preferences", handler.HandleGlobalPreferencesRequest)
+	mux.HandleFunc("/global/preferences", middleware.RequireServiceToken(serviceToken, handler.HandleGlobalPreferencesRequest))
+	mux.HandleFunc("/events", middleware.RequireServiceToken(serviceToken, handler.HandleEventsRequest))
+	mux.HandleFunc("/onboarding/genres", handler.HandleOnboardingGenresRequest)
+	mux.HandleFunc("/experiments", handler.HandleExperimentsRequest)
+	mux.HandleFunc("/experiments/", middleware.RequireServiceToken(serviceToken, handler.HandleExperimentsRequest))
 
 	mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
 
@@ -22,7 +28,7 @@ func SetupRoutes(handler *Handler) http.Handler {
 			if r.Method == http.MethodGet {
 				handler.HandleUserPreferencesRequest(w, r)
 			} else if r.Method == http.MethodPost {
-				handler.HandleUpdateUserPreferences(w, r)
+				middleware.RequireServiceToken(serviceToken, handler.HandleUpdateUserPreferences)(w, r)
 			} else {
 				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			}
@@ -32,7 +38,19 @@ func SetupRoutes(handler *Handler) http.Handler {
 		http.NotFound(w, r)
 	})
 
-	mux.HandleFunc("/users/", handler.HandleUsersRequest)
+	mux.HandleFunc("/users/", middleware.RequireServiceToken(serviceToken, handler.HandleUsersRequest))
+
+	mux.HandleFunc("/books/", func(w http.ResponseWriter, r *http.Request) {
+
+		parts := strings.Split(r.URL.Path, "/")
+
+		if len(parts) == 4 && parts[3] == "similar" {
+			handler.HandleSimilarBooksRequest(w, r)
+			return
+		}
+
+		http.NotFound(w, r)
+	})
 
 	fs := http.FileServer(http.Dir("./static"))
 	mux.Handle("/", fs)
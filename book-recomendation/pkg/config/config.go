@@ -2,12 +2,50 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
 	FriendServiceURL string
+	ModelService     ModelServiceConfig
 	Port             string
-	DB               DBConfig
+	// Recommender selects the recommendation engine: "hybrid", "native", "collaborative" or "python"
+	Recommender    string
+	CatalogRefresh time.Duration
+	// CFRebuild is how often the collaborative-filtering model is rebuilt
+	CFRebuild time.Duration
+	// PreferenceAggregate is how often preferences are learned from new
+	// interaction events, PreferenceHalfLife how fast old events fade
+	PreferenceAggregate time.Duration
+	PreferenceHalfLife  time.Duration
+	Weights             WeightsConfig
+	// ExperimentFile is the experiment to run, see services.Experiment; none
+	// when empty
+	ExperimentFile string
+	// ServiceToken is the secret book-service and the model service send
+	// with their writes; see middleware.RequireServiceToken
+	ServiceToken string
+	DB           DBConfig
+}
+
+// WeightsConfig holds the shares of each signal in hybrid recommendations
+type WeightsConfig struct {
+	Content       float64
+	Collaborative float64
+	Popularity    float64
+	Preferences   float64
+}
+
+// ModelServiceConfig tunes the client of the Python model service at
+// FriendServiceURL
+type ModelServiceConfig struct {
+	Timeout         time.Duration
+	Retries         int
+	Backoff         time.Duration
+	BreakerFailures int
+	BreakerCooldown time.Duration
+	CacheTTL        time.Duration
 }
 
 type DBConfig struct {
@@ -21,7 +59,28 @@ type DBConfig struct {
 func LoadConfig() Config {
 	return Config{
 		FriendServiceURL: getEnv("FRIEND_SERVICE_URL", "http://localhost/api/model"),
-		Port:             getEnv("PORT", "8080"),
+		ModelService: ModelServiceConfig{
+			Timeout:         getDurationEnv("MODEL_TIMEOUT", 5*time.Second),
+			Retries:         getIntEnv("MODEL_RETRIES", 2),
+			Backoff:         getDurationEnv("MODEL_BACKOFF", 100*time.Millisecond),
+			BreakerFailures: getIntEnv("MODEL_BREAKER_FAILURES", 5),
+			BreakerCooldown: getDurationEnv("MODEL_BREAKER_COOLDOWN", 30*time.Second),
+			CacheTTL:        getDurationEnv("MODEL_CACHE_TTL", 5*time.Minute),
+		},
+		Port:                getEnv("PORT", "8080"),
+		Recommender:         getEnv("RECOMMENDER", "hybrid"),
+		CatalogRefresh:      getIntervalEnv("CATALOG_REFRESH", 10*time.Minute),
+		CFRebuild:           getIntervalEnv("CF_REBUILD", 30*time.Minute),
+		PreferenceAggregate: getIntervalEnv("PREFERENCE_AGGREGATE", 5*time.Minute),
+		PreferenceHalfLife:  getDurationEnv("PREFERENCE_HALF_LIFE", 30*24*time.Hour),
+		Weights: WeightsConfig{
+			Content:       getFloatEnv("WEIGHT_CONTENT", 0.4),
+			Collaborative: getFloatEnv("WEIGHT_COLLABORATIVE", 0.35),
+			Popularity:    getFloatEnv("WEIGHT_POPULARITY", 0.1),
+			Preferences:   getFloatEnv("WEIGHT_PREFERENCES", 0.15),
+		},
+		ExperimentFile: getEnv("EXPERIMENT_FILE", ""),
+		ServiceToken:   getEnv("SERVICE_TOKEN", ""),
 		DB: DBConfig{
 			Host:     getEnv("DB_HOST", "159.223.84.254"),
 			Port:     getEnv("DB_PORT", "5432"),
@@ -38,3 +97,52 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue
+	}
+	return d
+}
+
+// getIntervalEnv reads the period of a background job. A ticker cannot run
+// every 0s, so durations that are not positive fall back to the default.
+func getIntervalEnv(key string, defaultValue time.Duration) time.Duration {
+	d := getDurationEnv(key, defaultValue)
+	if d <= 0 {
+		return defaultValue
+	}
+	return d
+}
+
+func getFloatEnv(key string, defaultValue float64) float64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return defaultValue
+	}
+	return f
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		return defaultValue
+	}
+	return i
+}
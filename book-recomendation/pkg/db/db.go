@@ -2,13 +2,16 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"time"
 
 	"book-recommendation-service/pkg/config"
 	"book-recommendation-service/pkg/models"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
@@ -105,10 +108,7 @@ func (r *Repository) GetUserPreferences(userID int) ([]models.UserPreference, er
 		return nil, fmt.Errorf("error iterating preferences: %v", err)
 	}
 
-	if len(preferences) == 0 {
-		return nil, fmt.Errorf("no preferences found for user")
-	}
-
+	// new users have none until they onboard or interact with books
 	return preferences, nil
 }
 
@@ -140,8 +140,66 @@ func (r *Repository) GetUserFavoriteBooks(userID int) ([]models.Book, error) {
 	return books, nil
 }
 
+// GetAllBooks returns the catalog from the books table shared with book-service
+func (r *Repository) GetAllBooks() ([]models.Book, error) {
+	query := `SELECT id, title, COALESCE(author, ''), COALESCE(main_genre, ''), COALESCE(sub_genre, ''), COALESCE(type, ''),
+                     COALESCE(rating, 0), COALESCE(people_rated, 0)::integer
+              FROM books
+              ORDER BY id`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %v", err)
+	}
+	defer rows.Close()
+
+	var books []models.Book
+	for rows.Next() {
+		var book models.Book
+		if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.MainGenre, &book.SubGenre, &book.Type, &book.Rating, &book.RatingsCount); err != nil {
+			return nil, fmt.Errorf("error scanning book row: %v", err)
+		}
+		books = append(books, book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating books: %v", err)
+	}
+
+	return books, nil
+}
+
+// GetBooksByIDs returns the books with the given ids, in no particular order
+func (r *Repository) GetBooksByIDs(ids []int) ([]models.Book, error) {
+	query := `SELECT id, title, COALESCE(author, ''), COALESCE(main_genre, ''), COALESCE(sub_genre, ''), COALESCE(type, ''),
+                     COALESCE(rating, 0), COALESCE(people_rated, 0)::integer
+              FROM books
+              WHERE id = ANY($1)`
+
+	rows, err := r.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("database error: %v", err)
+	}
+	defer rows.Close()
+
+	var books []models.Book
+	for rows.Next() {
+		var book models.Book
+		if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.MainGenre, &book.SubGenre, &book.Type, &book.Rating, &book.RatingsCount); err != nil {
+			return nil, fmt.Errorf("error scanning book row: %v", err)
+		}
+		books = append(books, book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating books: %v", err)
+	}
+
+	return books, nil
+}
+
 func (r *Repository) GetUserBookInteractions(userID int) ([]models.BookInteraction, error) {
-	query := `SELECT user_id, book_id, interaction_type, rating, created_at
+	query := `SELECT user_id, book_id, interaction_type, COALESCE(rating, 0), created_at
               FROM user_book_interactions
               WHERE user_id = $1
               ORDER BY created_at DESC`
@@ -168,6 +226,86 @@ func (r *Repository) GetUserBookInteractions(userID int) ([]models.BookInteracti
 	return interactions, nil
 }
 
+// GetAllInteractions returns every user-book signal: the ratings from
+// book-service as "rate" interactions followed by user_book_interactions
+func (r *Repository) GetAllInteractions() ([]models.BookInteraction, error) {
+	query := `SELECT user_id, book_id, 'rate', score, created_at
+              FROM ratings
+              UNION ALL
+              SELECT user_id, book_id, interaction_type, COALESCE(rating, 0), created_at
+              FROM user_book_interactions`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %v", err)
+	}
+	defer rows.Close()
+
+	var interactions []models.BookInteraction
+	for rows.Next() {
+		var interaction models.BookInteraction
+		if err := rows.Scan(&interaction.UserID, &interaction.BookID, &interaction.Type, &interaction.Rating, &interaction.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning interaction row: %v", err)
+		}
+		interactions = append(interactions, interaction)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating interactions: %v", err)
+	}
+
+	return interactions, nil
+}
+
+// ErrUnknownUserOrBook is returned when an interaction names a user or book
+// that does not exist
+var ErrUnknownUserOrBook = errors.New("unknown user or book")
+
+// InsertInteraction stores an interaction event and sets its CreatedAt
+func (r *Repository) InsertInteraction(interaction *models.BookInteraction) error {
+	query := `INSERT INTO user_book_interactions (user_id, book_id, interaction_type, rating)
+              VALUES ($1, $2, $3, NULLIF($4::real, 0))
+              RETURNING created_at`
+
+	err := r.db.QueryRow(query, interaction.UserID, interaction.BookID, interaction.Type, interaction.Rating).Scan(&interaction.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrUnknownUserOrBook
+		}
+		return fmt.Errorf("database error: %v", err)
+	}
+
+	return nil
+}
+
+// GetUsersWithInteractionsSince returns the users with an interaction
+// created after since
+func (r *Repository) GetUsersWithInteractionsSince(since time.Time) ([]int, error) {
+	query := `SELECT DISTINCT user_id FROM user_book_interactions WHERE created_at > $1`
+
+	rows, err := r.db.Query(query, since)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %v", err)
+	}
+	defer rows.Close()
+
+	var users []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("error scanning user row: %v", err)
+		}
+		users = append(users, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %v", err)
+	}
+
+	return users, nil
+}
+
 func (r *Repository) GetGlobalPreferences() (models.UserPreferencesMap, error) {
 	query := `SELECT category, value, avg_weight FROM global_preferences ORDER BY category, avg_weight DESC`
 
@@ -192,7 +330,7 @@ func (r *Repository) GetGlobalPreferences() (models.UserPreferencesMap, error) {
 			return nil, fmt.Errorf("error scanning preference row: %v", err)
 		}
 
-		normalizedWeight := weight / 5.0
+		normalizedWeight := weight / globalWeightScale
 
 		if _, exists := globalPrefs[category]; exists {
 			globalPrefs[category][value] = normalizedWeight
@@ -209,9 +347,226 @@ func (r *Repository) GetGlobalPreferences() (models.UserPreferencesMap, error) {
 	return globalPrefs, nil
 }
 
+// globalWeightScale is the scale avg_weight is stored in; preference weights
+// are between 0 and 1
+const globalWeightScale = 5.0
+
+// UpdateUserPreferences upserts the user's weights and counts each update of
+// a value, then recomputes the global averages of the values it touched, all
+// in one transaction
 func (r *Repository) UpdateUserPreferences(userID int, prefs models.UserPreferencesMap) error {
+	query := `INSERT INTO user_preferences (user_id, category, value, weight, count, updated_at)
+              VALUES ($1, $2, $3, $4, $5, NOW())
+              ON CONFLICT (user_id, category, value)
+              DO UPDATE SET weight = EXCLUDED.weight, count = user_preferences.count + EXCLUDED.count, updated_at = NOW()`
+
+	return r.writeUserPreferences(userID, flattenPreferences(prefs), query)
+}
+
+// SetLearnedPreferences stores preferences learned from interactions, where
+// the count is the number of interactions behind each weight
+func (r *Repository) SetLearnedPreferences(userID int, prefs []models.UserPreference) error {
+	query := `INSERT INTO user_preferences (user_id, category, value, weight, count, updated_at)
+              VALUES ($1, $2, $3, $4, $5, NOW())
+              ON CONFLICT (user_id, category, value)
+              DO UPDATE SET weight = EXCLUDED.weight, count = EXCLUDED.count, updated_at = NOW()`
+
+	entries := append([]models.UserPreference{}, prefs...)
+	sortPreferences(entries)
+
+	return r.writeUserPreferences(userID, entries, query)
+}
+
+// writeUserPreferences runs the upsert query, taking the user id, category,
+// value, weight and count of an entry, for every entry, then recomputes
+// the global averages of the values it touched, in one transaction
+func (r *Repository) writeUserPreferences(userID int, entries []models.UserPreference, upsert string) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("database error: %v", err)
+	}
+	defer tx.Rollback()
+
+	categories := make([]string, 0, len(entries))
+	values := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		if _, err := tx.Exec(upsert, userID, entry.Category, entry.Value, entry.Weight, entry.Count); err != nil {
+			return fmt.Errorf("database error: %v", err)
+		}
+		categories = append(categories, entry.Category)
+		values = append(values, entry.Value)
+	}
+
+	query := `INSERT INTO global_preferences (category, value, avg_weight, user_count, updated_at)
+              SELECT p.category, p.value, AVG(p.weight) * $3, COUNT(*), NOW()
+              FROM user_preferences p
+              JOIN unnest($1::text[], $2::text[]) AS t(category, value)
+                ON p.category = t.category AND p.value = t.value
+              GROUP BY p.category, p.value
+              ORDER BY p.category, p.value
+              ON CONFLICT (category, value)
+              DO UPDATE SET avg_weight = EXCLUDED.avg_weight, user_count = EXCLUDED.user_count, updated_at = NOW()`
+
+	if _, err := tx.Exec(query, pq.Array(categories), pq.Array(values), globalWeightScale); err != nil {
+		return fmt.Errorf("database error: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("database error: %v", err)
+	}
+
+	log.Printf("Updated %d preferences for user %d", len(entries), userID)
+	return nil
+}
+
+// UpdateGlobalPreferences seeds the global weights of values no user has a
+// preference for yet. avg_weight is otherwise the average user weight that
+// writeUserPreferences keeps up to date, so existing values are left alone
+// rather than replaced by averages computed elsewhere, such as model.py's.
+func (r *Repository) UpdateGlobalPreferences(prefs models.UserPreferencesMap) error {
+	entries := flattenPreferences(prefs)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("database error: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO global_preferences (category, value, avg_weight, updated_at)
+              VALUES ($1, $2, $3, NOW())
+              ON CONFLICT (category, value) DO NOTHING`
+
+	for _, entry := range entries {
+		if _, err := tx.Exec(query, entry.Category, entry.Value, entry.Weight*globalWeightScale); err != nil {
+			return fmt.Errorf("database error: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("database error: %v", err)
+	}
+
+	return nil
+}
+
+// flattenPreferences lists the preferences, each counting as one update
+func flattenPreferences(prefs models.UserPreferencesMap) []models.UserPreference {
+	var entries []models.UserPreference
+	for category, values := range prefs {
+		for value, weight := range values {
+			entries = append(entries, models.UserPreference{Category: category, Value: value, Weight: weight, Count: 1})
+		}
+	}
+
+	sortPreferences(entries)
+	return entries
+}
+
+// sortPreferences sorts by category and value, so concurrent upserts lock
+// rows in the same order
+func sortPreferences(entries []models.UserPreference) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Category != entries[j].Category {
+			return entries[i].Category < entries[j].Category
+		}
+		return entries[i].Value < entries[j].Value
+	})
+}
+
+// LogImpressions logs that the books were recommended to the user in their
+// experiment variant
+func (r *Repository) LogImpressions(assignment models.ExperimentAssignment, userID int, bookIDs []int) error {
+	query := `INSERT INTO experiment_events (experiment, variant, user_id, book_id, event_type)
+              SELECT $1, $2, $3, book_id, 'impression'
+              FROM unnest($4::bigint[]) AS book_id`
+
+	if _, err := r.db.Exec(query, assignment.Experiment, assignment.Variant, userID, pq.Array(bookIDs)); err != nil {
+		return fmt.Errorf("database error: %v", err)
+	}
+
+	return nil
+}
+
+// ErrNoImpression is returned for a click on a book that was not recommended
+// to the user in their variant
+var ErrNoImpression = errors.New("book was not recommended to the user")
+
+// LogClick logs that the user clicked a recommended book in their experiment
+// variant. Only clicks on books logged as impressions of the user count.
+func (r *Repository) LogClick(assignment models.ExperimentAssignment, userID, bookID int) error {
+	query := `INSERT INTO experiment_events (experiment, variant, user_id, book_id, event_type)
+              SELECT $1, $2, $3, $4, 'click'
+              WHERE EXISTS (
+                  SELECT 1 FROM experiment_events
+                  WHERE experiment = $1 AND variant = $2 AND user_id = $3 AND book_id = $4
+                      AND event_type = 'impression'
+              )`
+
+	result, err := r.db.Exec(query, assignment.Experiment, assignment.Variant, userID, bookID)
+	if err != nil {
+		return fmt.Errorf("database error: %v", err)
+	}
 
-	log.Printf("Updated preferences for user %d: %v", userID, prefs)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("database error: %v", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNoImpression
+	}
 
 	return nil
 }
+
+// GetExperimentCounts counts the users, impressions and clicks of every
+// variant of the experiment, in all and per user. Impressions and clicks are
+// distinct user and book pairs, so a book shown on every visit or clicked
+// twice counts once.
+func (r *Repository) GetExperimentCounts(experiment string) ([]models.VariantCounts, error) {
+	query := `SELECT variant, user_id,
+                     COUNT(DISTINCT book_id) FILTER (WHERE event_type = 'impression'),
+                     COUNT(DISTINCT book_id) FILTER (WHERE event_type = 'click')
+              FROM experiment_events
+              WHERE experiment = $1
+              GROUP BY variant, user_id
+              ORDER BY variant, user_id`
+
+	rows, err := r.db.Query(query, experiment)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %v", err)
+	}
+	defer rows.Close()
+
+	var counts []models.VariantCounts
+	for rows.Next() {
+		var variant string
+		var userID int
+		var user models.UserCounts
+		if err := rows.Scan(&variant, &userID, &user.Impressions, &user.Clicks); err != nil {
+			return nil, fmt.Errorf("error scanning variant row: %v", err)
+		}
+
+		if len(counts) == 0 || counts[len(counts)-1].Variant != variant {
+			counts = append(counts, models.VariantCounts{Variant: variant})
+		}
+		c := &counts[len(counts)-1]
+		c.Users++
+		c.Impressions += user.Impressions
+		c.Clicks += user.Clicks
+		c.PerUser = append(c.PerUser, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating variants: %v", err)
+	}
+
+	return counts, nil
+}
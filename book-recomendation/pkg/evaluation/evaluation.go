@@ -0,0 +1,332 @@
+// Package evaluation measures the recommenders offline: interactions are
+// split by time, each recommender is asked for the users of the earlier part
+// and is scored on how many of their later interactions it predicted.
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"book-recommendation-service/pkg/models"
+	"book-recommendation-service/pkg/services"
+)
+
+// relevantWeight is the InteractionWeight from which an interaction means the
+// user liked the book: a favorite, comment, purchase click or a rating of 4+
+// counts; a view or a rating of 3 or less does not
+const relevantWeight = 0.5
+
+// Options configure an evaluation
+type Options struct {
+	// K is the number of recommendations scored per user
+	K int
+	// TrainFraction is the share of interactions, oldest first, the
+	// recommenders learn from
+	TrainFraction float64
+	// HalfLife is how fast old interactions fade in learned preferences
+	HalfLife time.Duration
+	Weights  services.HybridWeights
+}
+
+// Result holds the metrics of one recommender, averaged over the users
+type Result struct {
+	Recommender string  `json:"recommender"`
+	Users       int     `json:"users"`
+	Precision   float64 `json:"precision"`
+	Recall      float64 `json:"recall"`
+	NDCG        float64 `json:"ndcg"`
+	// Coverage is the share of the catalog recommended to anyone
+	Coverage float64 `json:"coverage"`
+	// Novelty is the mean self-information, in bits, of the recommended
+	// books: the rarer they were in training, the higher
+	Novelty float64 `json:"novelty"`
+}
+
+type Report struct {
+	K                 int       `json:"k"`
+	Cutoff            time.Time `json:"cutoff"`
+	TrainInteractions int       `json:"train_interactions"`
+	TestInteractions  int       `json:"test_interactions"`
+	Results           []Result  `json:"results"`
+	// Notes are caveats the results should be read with
+	Notes []string `json:"notes,omitempty"`
+}
+
+type namedRecommender struct {
+	name        string
+	recommender services.Recommender
+}
+
+// Run evaluates every native recommender on the books and interactions
+func Run(ctx context.Context, books []models.Book, interactions []models.BookInteraction, opts Options) (*Report, error) {
+	if opts.K < 1 || opts.K > services.DefaultRecommendationCount {
+		return nil, errors.New("k must be between 1 and the number of recommendations returned")
+	}
+	if opts.TrainFraction <= 0 || opts.TrainFraction >= 1 {
+		return nil, errors.New("the train fraction must be between 0 and 1")
+	}
+
+	catalog := services.NewStaticCatalog(books)
+
+	train, test, cutoff := splitByTime(interactions, opts.TrainFraction)
+	if len(train) == 0 || len(test) == 0 {
+		return nil, errors.New("not enough interactions to split")
+	}
+
+	prefs := learnPreferences(train, catalog, cutoff, opts.HalfLife)
+
+	cf := services.NewCollaborativeRecommender(catalog, func() ([]models.BookInteraction, error) {
+		return train, nil
+	})
+	if err := cf.Rebuild(); err != nil {
+		return nil, err
+	}
+
+	recommenders := []namedRecommender{
+		{"native", services.NewContentRecommender(catalog, prefs)},
+		{"collaborative", cf},
+		{"hybrid", services.NewHybridRecommender(catalog, prefs, cf, opts.Weights)},
+		{"popular", services.NewPopularRecommender(catalog, prefs)},
+	}
+
+	// books are compared by their canonical catalog id, the first book of
+	// their title, as the recommenders return one book per title
+	canonical := func(bookID int) (int, bool) {
+		book, ok := catalog.FindByID(bookID)
+		if !ok {
+			return 0, false
+		}
+		book, ok = catalog.FindByTitle(book.Title)
+		return book.ID, ok
+	}
+
+	seen := map[int]map[int]bool{}
+	liked := map[int][]string{}
+	trainUsers := map[int]map[int]bool{}
+	for _, interaction := range train {
+		id, ok := canonical(interaction.BookID)
+		if !ok {
+			continue
+		}
+		if seen[interaction.UserID] == nil {
+			seen[interaction.UserID] = map[int]bool{}
+		}
+		if trainUsers[id] == nil {
+			trainUsers[id] = map[int]bool{}
+		}
+		trainUsers[id][interaction.UserID] = true
+
+		if !seen[interaction.UserID][id] && services.InteractionWeight(interaction) >= relevantWeight {
+			book, _ := catalog.FindByID(id)
+			liked[interaction.UserID] = append(liked[interaction.UserID], book.Title)
+		}
+		seen[interaction.UserID][id] = true
+	}
+
+	relevant := map[int]map[int]bool{}
+	for _, interaction := range test {
+		id, ok := canonical(interaction.BookID)
+		if !ok || seen[interaction.UserID] == nil || seen[interaction.UserID][id] {
+			continue
+		}
+		if services.InteractionWeight(interaction) < relevantWeight {
+			continue
+		}
+		if relevant[interaction.UserID] == nil {
+			relevant[interaction.UserID] = map[int]bool{}
+		}
+		relevant[interaction.UserID][id] = true
+	}
+
+	users := make([]int, 0, len(relevant))
+	for userID := range relevant {
+		users = append(users, userID)
+	}
+	sort.Ints(users)
+
+	titles := map[int]bool{}
+	for _, book := range books {
+		if id, ok := canonical(book.ID); ok {
+			titles[id] = true
+		}
+	}
+
+	report := &Report{
+		K:                 opts.K,
+		Cutoff:            cutoff,
+		TrainInteractions: len(train),
+		TestInteractions:  len(test),
+	}
+
+	for _, r := range recommenders {
+		result := Result{Recommender: r.name}
+		recommended := map[int]bool{}
+		var novelty float64
+		var items int
+
+		for _, userID := range users {
+			response, err := r.recommender.GetRecommendations(ctx, models.RecommendationRequest{
+				UserID:         userID,
+				UserBookTitles: liked[userID],
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			var ranked []int
+			for _, title := range response.RecommendedTitles {
+				if len(ranked) == opts.K {
+					break
+				}
+				if book, ok := catalog.FindByTitle(title); ok {
+					ranked = append(ranked, book.ID)
+				}
+			}
+
+			for _, id := range ranked {
+				recommended[id] = true
+				novelty -= math.Log2(float64(len(trainUsers[id])+1) / float64(len(seen)+1))
+				items++
+			}
+
+			precision, recall, ndcg := scoreRanking(ranked, relevant[userID], opts.K)
+			result.Precision += precision
+			result.Recall += recall
+			result.NDCG += ndcg
+		}
+
+		result.Users = len(users)
+		if len(users) > 0 {
+			result.Precision /= float64(len(users))
+			result.Recall /= float64(len(users))
+			result.NDCG /= float64(len(users))
+		}
+		if len(titles) > 0 {
+			result.Coverage = float64(len(recommended)) / float64(len(titles))
+		}
+		if items > 0 {
+			result.Novelty = novelty / float64(items)
+		}
+
+		report.Results = append(report.Results, result)
+	}
+
+	return report, nil
+}
+
+// scoreRanking scores the top k of ranked against the books relevant to the
+// user. A ranking shorter than k still has k slots, so it loses precision.
+func scoreRanking(ranked []int, relevant map[int]bool, k int) (precision, recall, ndcg float64) {
+	if len(relevant) == 0 {
+		return 0, 0, 0
+	}
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+
+	hits, dcg := 0, 0.0
+	for rank, id := range ranked {
+		if relevant[id] {
+			hits++
+			dcg += 1 / math.Log2(float64(rank+2))
+		}
+	}
+
+	idcg := 0.0
+	for rank := 0; rank < len(relevant) && rank < k; rank++ {
+		idcg += 1 / math.Log2(float64(rank+2))
+	}
+
+	return float64(hits) / float64(k), float64(hits) / float64(len(relevant)), dcg / idcg
+}
+
+// splitByTime puts the oldest fraction of the interactions in train and the
+// rest in test. Interactions at the cutoff time all go to test.
+func splitByTime(interactions []models.BookInteraction, fraction float64) (train, test []models.BookInteraction, cutoff time.Time) {
+	type timed struct {
+		interaction models.BookInteraction
+		at          time.Time
+	}
+
+	sorted := make([]timed, 0, len(interactions))
+	for _, interaction := range interactions {
+		at, err := time.Parse(time.RFC3339Nano, interaction.CreatedAt)
+		if err != nil {
+			continue
+		}
+		sorted = append(sorted, timed{interaction, at})
+	}
+	if len(sorted) == 0 {
+		return nil, nil, time.Time{}
+	}
+
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return sorted[a].at.Before(sorted[b].at)
+	})
+
+	cutoff = sorted[int(float64(len(sorted))*fraction)].at
+	for _, t := range sorted {
+		if t.at.Before(cutoff) {
+			train = append(train, t.interaction)
+		} else {
+			test = append(test, t.interaction)
+		}
+	}
+
+	return train, test, cutoff
+}
+
+// memoryPreferences serves learned preferences to the recommenders in place
+// of the database
+type memoryPreferences struct {
+	users   map[int][]models.UserPreference
+	globals models.UserPreferencesMap
+}
+
+func (m *memoryPreferences) GetUserPreferences(userID int) ([]models.UserPreference, error) {
+	return m.users[userID], nil
+}
+
+func (m *memoryPreferences) GetGlobalPreferences() (models.UserPreferencesMap, error) {
+	return m.globals, nil
+}
+
+// learnPreferences learns every user's preferences from their training
+// interactions as the aggregator would at the cutoff, and averages them into
+// global preferences
+func learnPreferences(train []models.BookInteraction, catalog *services.Catalog, cutoff time.Time, halfLife time.Duration) *memoryPreferences {
+	byUser := map[int][]models.BookInteraction{}
+	for _, interaction := range train {
+		byUser[interaction.UserID] = append(byUser[interaction.UserID], interaction)
+	}
+
+	prefs := &memoryPreferences{users: map[int][]models.UserPreference{}, globals: models.UserPreferencesMap{}}
+	sums := map[string]map[string]float64{}
+	counts := map[string]map[string]int{}
+
+	for userID, interactions := range byUser {
+		learned := services.LearnPreferences(interactions, catalog, cutoff, halfLife)
+		prefs.users[userID] = learned
+
+		for _, pref := range learned {
+			if sums[pref.Category] == nil {
+				sums[pref.Category] = map[string]float64{}
+				counts[pref.Category] = map[string]int{}
+			}
+			sums[pref.Category][pref.Value] += pref.Weight
+			counts[pref.Category][pref.Value]++
+		}
+	}
+
+	for category, values := range sums {
+		prefs.globals[category] = map[string]float64{}
+		for value, sum := range values {
+			prefs.globals[category][value] = sum / float64(counts[category][value])
+		}
+	}
+
+	return prefs
+}
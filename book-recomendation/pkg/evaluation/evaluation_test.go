@@ -0,0 +1,162 @@
+package evaluation
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"book-recommendation-service/pkg/models"
+	"book-recommendation-service/pkg/services"
+)
+
+func TestScoreRanking(t *testing.T) {
+	tests := []struct {
+		name     string
+		ranked   []int
+		relevant map[int]bool
+		k        int
+		// precision, recall and ndcg
+		want [3]float64
+	}{
+		{"perfect", []int{1, 2}, map[int]bool{1: true, 2: true}, 2, [3]float64{1, 1, 1}},
+		{"hit at the second rank", []int{1, 2}, map[int]bool{2: true}, 2, [3]float64{0.5, 1, 1 / math.Log2(3)}},
+		{"no hits", []int{1, 2}, map[int]bool{3: true}, 2, [3]float64{0, 0, 0}},
+		{"short ranking", []int{1}, map[int]bool{1: true, 2: true}, 2, [3]float64{0.5, 0.5, 1 / (1 + 1/math.Log2(3))}},
+		{"more relevant books than k", []int{1, 2}, map[int]bool{1: true, 2: true, 3: true}, 2, [3]float64{1, 2.0 / 3, 1}},
+		{"ranking cut at k", []int{9, 1}, map[int]bool{1: true}, 1, [3]float64{0, 0, 0}},
+		{"nothing relevant", []int{1}, map[int]bool{}, 1, [3]float64{0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			precision, recall, ndcg := scoreRanking(tt.ranked, tt.relevant, tt.k)
+			got := [3]float64{precision, recall, ndcg}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Fatalf("scoreRanking() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitByTime(t *testing.T) {
+	at := func(day int) string {
+		return time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC).Format(time.RFC3339Nano)
+	}
+	interactions := []models.BookInteraction{
+		{BookID: 5, CreatedAt: at(5)},
+		{BookID: 1, CreatedAt: at(1)},
+		{BookID: 4, CreatedAt: at(4)},
+		{BookID: 0, CreatedAt: "not a time"},
+		{BookID: 2, CreatedAt: at(2)},
+		{BookID: 40, CreatedAt: at(4)},
+		{BookID: 3, CreatedAt: at(3)},
+	}
+
+	train, test, cutoff := splitByTime(interactions, 0.5)
+
+	if want := time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC); !cutoff.Equal(want) {
+		t.Errorf("cutoff = %v, want %v", cutoff, want)
+	}
+	books := func(interactions []models.BookInteraction) []int {
+		var ids []int
+		for _, interaction := range interactions {
+			ids = append(ids, interaction.BookID)
+		}
+		return ids
+	}
+	if got, want := books(train), []int{1, 2, 3}; !equalInts(got, want) {
+		t.Errorf("train = %v, want %v", got, want)
+	}
+	// both interactions at the cutoff go to test
+	if got, want := books(test), []int{4, 40, 5}; !equalInts(got, want) {
+		t.Errorf("test = %v, want %v", got, want)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRun(t *testing.T) {
+	var books []models.Book
+	for i, subGenre := range []string{"a", "b", "c", "d"} {
+		for j := 0; j < 15; j++ {
+			id := i*15 + j
+			books = append(books, models.Book{
+				ID:           id,
+				Title:        "Book " + string(rune('A'+i)) + string(rune('a'+j)),
+				Author:       "Author " + subGenre,
+				MainGenre:    "Fiction",
+				SubGenre:     subGenre,
+				Type:         "Paperback",
+				Rating:       4,
+				RatingsCount: id,
+			})
+		}
+	}
+	interactions := SyntheticInteractions(books, FixtureOptions{
+		Users: 50,
+		Seed:  1,
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Span:  30 * 24 * time.Hour,
+	})
+	opts := Options{
+		K:             5,
+		TrainFraction: 0.8,
+		HalfLife:      30 * 24 * time.Hour,
+		Weights:       services.HybridWeights{Content: 0.4, Collaborative: 0.35, Popularity: 0.1, Preferences: 0.15},
+	}
+
+	report, err := Run(context.Background(), books, interactions, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.TrainInteractions+report.TestInteractions != len(interactions) {
+		t.Errorf("split %d and %d interactions, want %d in all", report.TrainInteractions, report.TestInteractions, len(interactions))
+	}
+	if len(report.Results) != 4 {
+		t.Fatalf("got %d results, want one per recommender", len(report.Results))
+	}
+	for _, r := range report.Results {
+		if r.Users == 0 {
+			t.Errorf("%s was scored on no users", r.Recommender)
+		}
+		for name, metric := range map[string]float64{"precision": r.Precision, "recall": r.Recall, "ndcg": r.NDCG, "coverage": r.Coverage} {
+			if metric < 0 || metric > 1 || math.IsNaN(metric) {
+				t.Errorf("%s %s = %v, want between 0 and 1", r.Recommender, name, metric)
+			}
+		}
+	}
+}
+
+func TestRunOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"k of 0", Options{K: 0, TrainFraction: 0.8}},
+		{"k above the recommendations returned", Options{K: services.DefaultRecommendationCount + 1, TrainFraction: 0.8}},
+		{"train fraction of 1", Options{K: 5, TrainFraction: 1}},
+		{"no interactions", Options{K: 5, TrainFraction: 0.8}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Run(context.Background(), nil, nil, tt.opts); err == nil {
+				t.Error("Run() returned no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package evaluation
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"book-recommendation-service/pkg/models"
+)
+
+// LoadBooksCSV reads the books of Books_df.csv, the dataset the books table
+// was seeded from. A book's id is its row index, as in the seed migration.
+func LoadBooksCSV(path string) ([]models.Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s header: %v", path, err)
+	}
+
+	column := map[string]int{}
+	for i, name := range header {
+		column[name] = i
+	}
+	for _, name := range []string{"", "Title", "Author", "Main Genre", "Sub Genre", "Type", "Rating", "No. of People rated"} {
+		if _, ok := column[name]; !ok {
+			return nil, fmt.Errorf("%s has no %q column", path, name)
+		}
+	}
+
+	var books []models.Book
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %v", path, err)
+		}
+
+		id, err := strconv.Atoi(record[column[""]])
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: invalid index %q", path, record[column[""]])
+		}
+		rating, _ := strconv.ParseFloat(record[column["Rating"]], 64)
+		rated, _ := strconv.ParseFloat(record[column["No. of People rated"]], 64)
+
+		books = append(books, models.Book{
+			ID:           id,
+			Title:        strings.TrimSpace(record[column["Title"]]),
+			Author:       strings.TrimSpace(record[column["Author"]]),
+			MainGenre:    record[column["Main Genre"]],
+			SubGenre:     record[column["Sub Genre"]],
+			Type:         record[column["Type"]],
+			Rating:       rating,
+			RatingsCount: int(rated),
+		})
+	}
+	if len(books) == 0 {
+		return nil, fmt.Errorf("%s has no books", path)
+	}
+
+	return books, nil
+}
+
+// FixtureBias is the caveat of results on synthetic interactions. Their users
+// like whole sub-genres, exactly what the content model scores, while real
+// readers' tastes cut across genres.
+const FixtureBias = "synthetic users like whole sub-genres, which favors the content-based recommenders (native, hybrid) over collaborative; compare them on real interactions (-source db) before choosing"
+
+// FixtureOptions shape the synthetic interactions of a fixture
+type FixtureOptions struct {
+	Users int
+	Seed  int64
+	// Start and Span are the period the interactions are spread over
+	Start time.Time
+	Span  time.Duration
+}
+
+// SyntheticInteractions generates interactions for books without any real
+// history. Each user has a taste of one to three sub-genres; most of their
+// interactions are with books of those, the rest with books picked by
+// popularity. Interactions with their taste are rated higher, so there is
+// something for the recommenders to learn. The same seed gives the same data.
+// Without books there are no interactions.
+func SyntheticInteractions(books []models.Book, opts FixtureOptions) []models.BookInteraction {
+	if len(books) == 0 {
+		return nil
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	bySubGenre := map[string][]int{}
+	var subGenres []string
+	for i, book := range books {
+		if _, ok := bySubGenre[book.SubGenre]; !ok {
+			subGenres = append(subGenres, book.SubGenre)
+		}
+		bySubGenre[book.SubGenre] = append(bySubGenre[book.SubGenre], i)
+	}
+	sort.Strings(subGenres)
+
+	// popularity picks are proportional to the number of ratings
+	cumulative := make([]float64, len(books))
+	var total float64
+	for i, book := range books {
+		total += float64(book.RatingsCount) + 1
+		cumulative[i] = total
+	}
+	popular := func() int {
+		return sort.SearchFloat64s(cumulative, rng.Float64()*total)
+	}
+
+	var interactions []models.BookInteraction
+	for userID := 1; userID <= opts.Users; userID++ {
+		taste := make([]string, 1+rng.Intn(3))
+		for i := range taste {
+			taste[i] = subGenres[rng.Intn(len(subGenres))]
+		}
+
+		n := 5 + rng.Intn(36)
+		for j := 0; j < n; j++ {
+			var book int
+			liked := rng.Float64() < 0.7
+			if liked {
+				candidates := bySubGenre[taste[rng.Intn(len(taste))]]
+				book = candidates[rng.Intn(len(candidates))]
+			} else {
+				book = popular()
+			}
+
+			at := opts.Start
+			if opts.Span > 0 {
+				at = at.Add(time.Duration(rng.Int63n(int64(opts.Span))))
+			}
+
+			interaction := models.BookInteraction{
+				UserID:    userID,
+				BookID:    books[book].ID,
+				CreatedAt: at.Format(time.RFC3339Nano),
+			}
+
+			switch p := rng.Float64(); {
+			case p < 0.5:
+				interaction.Type = "view"
+			case p < 0.65:
+				interaction.Type = "favorite"
+			case p < 0.85:
+				interaction.Type = "rate"
+				if liked {
+					interaction.Rating = float64(4 + rng.Intn(2))
+				} else {
+					interaction.Rating = float64(1 + rng.Intn(5))
+				}
+			case p < 0.9:
+				interaction.Type = "comment"
+			default:
+				interaction.Type = "purchase_click"
+			}
+
+			interactions = append(interactions, interaction)
+		}
+	}
+
+	return interactions
+}
@@ -0,0 +1,72 @@
+package evaluation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"book-recommendation-service/pkg/models"
+)
+
+const booksHeader = ",Title,Author,Main Genre,Sub Genre,Type,Price,Rating,No. of People rated,URLs\n"
+
+func writeCSV(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "books.csv")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadBooksCSV(t *testing.T) {
+	path := writeCSV(t, booksHeader+"0, Dune ,Frank Herbert,Fiction,Science Fiction,Paperback,₹300,4.5,120,x\n")
+
+	books, err := LoadBooksCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.Book{ID: 0, Title: "Dune", Author: "Frank Herbert", MainGenre: "Fiction", SubGenre: "Science Fiction", Type: "Paperback", Rating: 4.5, RatingsCount: 120}
+	if len(books) != 1 || books[0] != want {
+		t.Errorf("LoadBooksCSV() = %+v, want [%+v]", books, want)
+	}
+}
+
+func TestLoadBooksCSVWithoutBooks(t *testing.T) {
+	if _, err := LoadBooksCSV(writeCSV(t, booksHeader)); err == nil {
+		t.Error("LoadBooksCSV() of a CSV without rows returned no error")
+	}
+}
+
+func TestSyntheticInteractions(t *testing.T) {
+	books := []models.Book{
+		{ID: 10, Title: "A", SubGenre: "x"},
+		{ID: 11, Title: "B", SubGenre: "y", RatingsCount: 50},
+	}
+	opts := FixtureOptions{Users: 20, Seed: 1, Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Span: 24 * time.Hour}
+
+	interactions := SyntheticInteractions(books, opts)
+	if len(interactions) < opts.Users*5 {
+		t.Fatalf("got %d interactions, want at least 5 per user", len(interactions))
+	}
+	for _, interaction := range interactions {
+		if interaction.BookID != 10 && interaction.BookID != 11 {
+			t.Fatalf("interaction with unknown book %d", interaction.BookID)
+		}
+		if interaction.UserID < 1 || interaction.UserID > opts.Users {
+			t.Fatalf("interaction of unknown user %d", interaction.UserID)
+		}
+	}
+
+	again := SyntheticInteractions(books, opts)
+	for i := range interactions {
+		if interactions[i] != again[i] {
+			t.Fatalf("the same seed gave different interactions at %d: %+v and %+v", i, interactions[i], again[i])
+		}
+	}
+
+	if got := SyntheticInteractions(nil, opts); len(got) != 0 {
+		t.Errorf("got %d interactions without books, want none", len(got))
+	}
+}
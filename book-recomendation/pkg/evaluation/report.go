@@ -0,0 +1,36 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// WriteTable writes the report as an aligned text table
+func WriteTable(w io.Writer, report *Report) error {
+	fmt.Fprintf(w, "Cutoff %s, %d train and %d test interactions\n\n",
+		report.Cutoff.Format("2006-01-02 15:04"), report.TrainInteractions, report.TestInteractions)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "recommender\tusers\tprecision@%d\trecall@%d\tndcg@%d\tcoverage\tnovelty\t\n", report.K, report.K, report.K)
+	for _, r := range report.Results {
+		fmt.Fprintf(tw, "%s\t%d\t%.4f\t%.4f\t%.4f\t%.4f\t%.2f\t\n",
+			r.Recommender, r.Users, r.Precision, r.Recall, r.NDCG, r.Coverage, r.Novelty)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, note := range report.Notes {
+		fmt.Fprintf(w, "\nNote: %s\n", note)
+	}
+	return nil
+}
+
+// WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"net/http"
 )
 
@@ -38,3 +39,28 @@ func CORS(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// ServiceTokenHeader carries the secret shared by the services allowed to
+// write to this one
+const ServiceTokenHeader = "X-Service-Token"
+
+// RequireServiceToken lets GET requests through and refuses any other
+// request that does not carry token in the X-Service-Token header. The write
+// endpoints are reachable through the public proxy, so without a token
+// configured they refuse every write.
+func RequireServiceToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next(w, r)
+			return
+		}
+
+		given := r.Header.Get(ServiceTokenHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireServiceToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		header string
+		want   int
+	}{
+		{"read without a token", "secret", http.MethodGet, "", http.StatusOK},
+		{"write with the token", "secret", http.MethodPost, "secret", http.StatusOK},
+		{"write without a token", "secret", http.MethodPost, "", http.StatusForbidden},
+		{"write with a wrong token", "secret", http.MethodPost, "secreT", http.StatusForbidden},
+		{"write when no token is configured", "", http.MethodPost, "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireServiceToken(tt.token, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			r := httptest.NewRequest(tt.method, "/events", nil)
+			if tt.header != "" {
+				r.Header.Set(ServiceTokenHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -17,12 +17,14 @@ type UserPreference struct {
 }
 
 type Book struct {
-	ID        int    `json:"id"`
-	Title     string `json:"title"`
-	Author    string `json:"author"`
-	MainGenre string `json:"main_genre"`
-	SubGenre  string `json:"sub_genre"`
-	Type      string `json:"type"`
+	ID           int     `json:"id"`
+	Title        string  `json:"title"`
+	Author       string  `json:"author"`
+	MainGenre    string  `json:"main_genre"`
+	SubGenre     string  `json:"sub_genre"`
+	Type         string  `json:"type"`
+	Rating       float64 `json:"rating"`
+	RatingsCount int     `json:"ratings_count"`
 }
 
 type BookInteraction struct {
@@ -33,13 +35,119 @@ type BookInteraction struct {
 	CreatedAt string  `json:"created_at"`
 }
 
+// InteractionEvent is an interaction posted to /events. Callers that only
+// know a book's title, such as favorites, may name it by BookTitle instead.
+type InteractionEvent struct {
+	UserID    int     `json:"user_id"`
+	BookID    int     `json:"book_id"`
+	BookTitle string  `json:"book_title,omitempty"`
+	Type      string  `json:"interaction_type"`
+	Rating    float64 `json:"rating,omitempty"`
+}
+
+// RecommendationRequest names the user's books by id, by title or both.
+// Titles are kept for older callers and the Python model service.
 type RecommendationRequest struct {
 	UserID         int      `json:"user_id"`
+	BookIDs        []int    `json:"book_ids,omitempty"`
 	UserBookTitles []string `json:"user_book_titles"`
 }
 
+// RecommendationResponse holds the ranked recommendations. RecommendedTitles
+// repeats their titles for title-only callers.
 type RecommendationResponse struct {
-	RecommendedTitles []string `json:"recommended_titles"`
+	RecommendedTitles []string         `json:"recommended_titles"`
+	Recommendations   []Recommendation `json:"recommendations"`
+	// Experiment is the experiment variant that served the user, if any
+	Experiment *ExperimentAssignment `json:"experiment,omitempty"`
+}
+
+// Recommendation is a recommended book with its metadata, its score and why
+// it was chosen
+type Recommendation struct {
+	BookID       int      `json:"book_id"`
+	Title        string   `json:"title"`
+	Author       string   `json:"author"`
+	MainGenre    string   `json:"main_genre"`
+	SubGenre     string   `json:"sub_genre"`
+	Type         string   `json:"type"`
+	Rating       float64  `json:"rating"`
+	RatingsCount int      `json:"ratings_count"`
+	Score        float64  `json:"score"`
+	Reasons      []Reason `json:"reasons"`
+}
+
+// Reason explains a recommendation: Code is stable for clients to switch on,
+// Message is the text to show, e.g. "because you liked Dune"
+type Reason struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ScoredBook is a recommended book with its similarity or relevance score
+type ScoredBook struct {
+	BookID int     `json:"book_id"`
+	Title  string  `json:"title"`
+	Score  float64 `json:"score"`
 }
 
 type UserPreferencesMap map[string]map[string]float64
+
+// OnboardingGenre is a main genre offered to new users, with some of its
+// sub-genres and sample books of each
+type OnboardingGenre struct {
+	MainGenre string               `json:"main_genre"`
+	SubGenres []OnboardingSubGenre `json:"sub_genres"`
+}
+
+type OnboardingSubGenre struct {
+	SubGenre string `json:"sub_genre"`
+	Books    []Book `json:"books"`
+}
+
+// OnboardingSelection is what a new user picked in the onboarding questionnaire
+type OnboardingSelection struct {
+	MainGenres []string `json:"main_genres"`
+	SubGenres  []string `json:"sub_genres"`
+	Authors    []string `json:"authors"`
+	BookIDs    []int    `json:"book_ids"`
+}
+
+// ExperimentAssignment is the variant of an experiment a user is bucketed into
+type ExperimentAssignment struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+}
+
+// ExperimentClick is a click on a recommended book, posted by book-service
+type ExperimentClick struct {
+	UserID int `json:"user_id"`
+	BookID int `json:"book_id"`
+}
+
+// VariantCounts are the impressions and clicks logged for a variant, as
+// distinct user and book pairs
+type VariantCounts struct {
+	Variant     string `json:"variant"`
+	Users       int    `json:"users"`
+	Impressions int    `json:"impressions"`
+	Clicks      int    `json:"clicks"`
+	// PerUser are the counts of every user of the variant, which the
+	// confidence interval needs as one user's impressions are not independent
+	PerUser []UserCounts `json:"-"`
+}
+
+// UserCounts are one user's impressions and clicks in a variant
+type UserCounts struct {
+	Impressions int
+	Clicks      int
+}
+
+// VariantResult is a variant's click-through rate with its 95% confidence
+// interval
+type VariantResult struct {
+	VariantCounts
+	CTR    float64 `json:"ctr"`
+	CILow  float64 `json:"ci_low"`
+	CIHigh float64 `json:"ci_high"`
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	b := newCircuitBreaker(3, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if !b.allow() {
+			t.Fatalf("call %d was rejected before the threshold", i+1)
+		}
+		b.failure()
+	}
+	if !b.allow() {
+		t.Fatal("the third call was rejected")
+	}
+	b.failure()
+
+	if b.allow() {
+		t.Error("the breaker let a call through after 3 failures")
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	b := newCircuitBreaker(2, time.Hour)
+
+	b.failure()
+	b.success()
+	b.failure()
+
+	if !b.allow() {
+		t.Error("failures that were not in a row opened the breaker")
+	}
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	tests := []struct {
+		name string
+		// result ends the probe
+		result   func(b *circuitBreaker)
+		wantOpen bool
+	}{
+		{"successful probe closes", (*circuitBreaker).success, false},
+		{"failed probe opens again", (*circuitBreaker).failure, true},
+		{"released probe lets another through", (*circuitBreaker).release, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCircuitBreaker(1, time.Hour)
+			b.failure()
+			if b.allow() {
+				t.Fatal("the breaker did not open")
+			}
+
+			// the cooldown is over
+			b.openedAt = time.Now().Add(-2 * time.Hour)
+			if !b.allow() {
+				t.Fatal("no probe was let through after the cooldown")
+			}
+			if b.allow() {
+				t.Fatal("a second call was let through while probing")
+			}
+
+			tt.result(b)
+
+			if got := !b.allow(); got != tt.wantOpen {
+				t.Errorf("open = %v, want %v", got, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := newCircuitBreaker(0, time.Hour)
+	for i := 0; i < 10; i++ {
+		b.failure()
+	}
+	if !b.allow() {
+		t.Error("a breaker with a threshold of 0 rejected a call")
+	}
+}
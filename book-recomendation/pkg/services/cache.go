@@ -0,0 +1,84 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"book-recommendation-service/pkg/models"
+)
+
+// maxCachedResponses bounds the response cache; expired entries are swept
+// when it is reached
+const maxCachedResponses = 10000
+
+// responseCache keeps recommendations per user and set of books for a TTL
+type responseCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	response *models.RecommendationResponse
+	expires  time.Time
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{ttl: ttl, entries: map[string]cachedResponse{}}
+}
+
+// cacheKey is the user and their books, in any order
+func cacheKey(req models.RecommendationRequest) string {
+	titles := make([]string, 0, len(req.UserBookTitles))
+	for _, title := range req.UserBookTitles {
+		titles = append(titles, normalizeTitle(title))
+	}
+	sort.Strings(titles)
+	return fmt.Sprintf("%d|%s", req.UserID, strings.Join(titles, "|"))
+}
+
+// get returns a copy of the cached response, which callers may modify
+func (c *responseCache) get(req models.RecommendationRequest) (*models.RecommendationResponse, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[cacheKey(req)]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+
+	response := *entry.response
+	return &response, true
+}
+
+func (c *responseCache) put(req models.RecommendationRequest, response *models.RecommendationResponse) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= maxCachedResponses {
+		for key, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	if len(c.entries) >= maxCachedResponses {
+		return
+	}
+
+	stored := *response
+	c.entries[cacheKey(req)] = cachedResponse{response: &stored, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"book-recommendation-service/pkg/models"
+)
+
+// Attributes are the book features recommendations and preferences are built
+// from, named as in Books_df.csv and model.py
+var Attributes = []string{"Main Genre", "Sub Genre", "Type", "Author"}
+
+// AttributeValue returns the book's value for one of the Attributes
+func AttributeValue(book models.Book, attribute string) string {
+	switch attribute {
+	case "Main Genre":
+		return book.MainGenre
+	case "Sub Genre":
+		return book.SubGenre
+	case "Type":
+		return book.Type
+	case "Author":
+		return book.Author
+	}
+	return ""
+}
+
+// Catalog is an in-memory copy of the books table, reloaded periodically
+type Catalog struct {
+	load func() ([]models.Book, error)
+
+	mu       sync.RWMutex
+	snapshot *catalogSnapshot
+}
+
+type catalogSnapshot struct {
+	books   []models.Book
+	byTitle map[string]int
+	byID    map[int]int
+	// features[i] holds the one-hot feature ids of books[i], one per attribute it has
+	features [][]int
+	// featureIDs maps attribute and value to a feature id
+	featureIDs map[string]map[string]int
+	// authors maps a normalized author name to the spelling books use
+	authors map[string]string
+}
+
+func NewCatalog(load func() ([]models.Book, error)) *Catalog {
+	return &Catalog{load: load}
+}
+
+// NewStaticCatalog returns a catalog of a fixed list of books
+func NewStaticCatalog(books []models.Book) *Catalog {
+	c := &Catalog{load: func() ([]models.Book, error) { return books, nil }}
+	c.Refresh()
+	return c
+}
+
+func (c *Catalog) Refresh() error {
+	books, err := c.load()
+	if err != nil {
+		return err
+	}
+
+	books, authors := canonicalAuthors(books)
+
+	snapshot := &catalogSnapshot{
+		books:      books,
+		byTitle:    make(map[string]int, len(books)),
+		byID:       make(map[int]int, len(books)),
+		features:   make([][]int, len(books)),
+		featureIDs: make(map[string]map[string]int, len(Attributes)),
+		authors:    authors,
+	}
+
+	nextFeature := 0
+	for _, attribute := range Attributes {
+		snapshot.featureIDs[attribute] = map[string]int{}
+	}
+
+	for i, book := range books {
+		snapshot.byID[book.ID] = i
+		if _, exists := snapshot.byTitle[normalizeTitle(book.Title)]; !exists {
+			snapshot.byTitle[normalizeTitle(book.Title)] = i
+		}
+
+		for _, attribute := range Attributes {
+			value := AttributeValue(book, attribute)
+			if value == "" {
+				continue
+			}
+			id, exists := snapshot.featureIDs[attribute][value]
+			if !exists {
+				id = nextFeature
+				nextFeature++
+				snapshot.featureIDs[attribute][value] = id
+			}
+			snapshot.features[i] = append(snapshot.features[i], id)
+		}
+	}
+
+	c.mu.Lock()
+	c.snapshot = snapshot
+	c.mu.Unlock()
+
+	return nil
+}
+
+// StartRefresh reloads the catalog on every tick of interval
+func (c *Catalog) StartRefresh(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := c.Refresh(); err != nil {
+				log.Printf("Error refreshing book catalog: %v", err)
+			}
+		}
+	}()
+}
+
+func (c *Catalog) current() *catalogSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.snapshot == nil {
+		return &catalogSnapshot{}
+	}
+	return c.snapshot
+}
+
+// Books returns every book in the catalog
+func (c *Catalog) Books() []models.Book {
+	return c.current().books
+}
+
+// FindByTitle looks a book up by title, ignoring case, spacing and punctuation
+func (c *Catalog) FindByTitle(title string) (models.Book, bool) {
+	s := c.current()
+	i, ok := s.byTitle[normalizeTitle(title)]
+	if !ok {
+		return models.Book{}, false
+	}
+	return s.books[i], true
+}
+
+func (c *Catalog) FindByID(id int) (models.Book, bool) {
+	s := c.current()
+	i, ok := s.byID[id]
+	if !ok {
+		return models.Book{}, false
+	}
+	return s.books[i], true
+}
+
+// CanonicalAuthor returns the spelling the catalog uses for an author, so
+// "J.K. Rowling" and "J. K. Rowling" are one preference. Unknown authors are
+// returned as they are.
+func (c *Catalog) CanonicalAuthor(name string) string {
+	if canonical, ok := c.current().authors[normalizeTitle(name)]; ok {
+		return canonical
+	}
+	return name
+}
+
+// canonicalPreferences merges the Author preferences of spellings of the same
+// author, keeping the strongest weight
+func (c *Catalog) canonicalPreferences(prefs models.UserPreferencesMap) models.UserPreferencesMap {
+	if len(prefs["Author"]) == 0 {
+		return prefs
+	}
+
+	merged := make(models.UserPreferencesMap, len(prefs))
+	for attribute, values := range prefs {
+		merged[attribute] = values
+	}
+
+	authors := make(map[string]float64, len(prefs["Author"]))
+	for name, weight := range prefs["Author"] {
+		name = c.CanonicalAuthor(name)
+		if w, exists := authors[name]; !exists || weight > w {
+			authors[name] = weight
+		}
+	}
+	merged["Author"] = authors
+
+	return merged
+}
+
+// canonicalAuthors gives every book the most used spelling of its author,
+// comparing names like titles. books is copied, not modified.
+func canonicalAuthors(books []models.Book) ([]models.Book, map[string]string) {
+	uses := map[string]map[string]int{}
+	for _, book := range books {
+		key := normalizeTitle(book.Author)
+		if key == "" {
+			continue
+		}
+		if uses[key] == nil {
+			uses[key] = map[string]int{}
+		}
+		uses[key][book.Author]++
+	}
+
+	authors := make(map[string]string, len(uses))
+	for key, spellings := range uses {
+		best := ""
+		for spelling, n := range spellings {
+			if best == "" || n > spellings[best] || (n == spellings[best] && spelling < best) {
+				best = spelling
+			}
+		}
+		authors[key] = best
+	}
+
+	canonical := make([]models.Book, len(books))
+	for i, book := range books {
+		if name, ok := authors[normalizeTitle(book.Author)]; ok {
+			book.Author = name
+		}
+		canonical[i] = book
+	}
+
+	return canonical, authors
+}
+
+func normalizeTitle(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"book-recommendation-service/pkg/models"
+)
+
+const (
+	// maxItemsPerUser bounds the pairs a single heavy user contributes
+	maxItemsPerUser = 200
+	// maxNeighbors is how many similar books are kept per book
+	maxNeighbors = 50
+)
+
+// InteractionTypes are the interactions accepted by /events
+var InteractionTypes = []string{"view", "favorite", "rate", "comment", "purchase_click"}
+
+// InteractionWeight is how strongly an interaction says the user liked the
+// book, between 0 and 1. Ratings of 2 and below count as not liking it.
+func InteractionWeight(interaction models.BookInteraction) float64 {
+	switch interaction.Type {
+	case "rate", "rating":
+		switch {
+		case interaction.Rating >= 4:
+			return interaction.Rating / 5
+		case interaction.Rating >= 3:
+			return 0.2
+		default:
+			return 0
+		}
+	case "favorite":
+		return 1
+	case "purchase_click":
+		return 0.8
+	case "comment":
+		return 0.5
+	case "view":
+		return 0.2
+	default:
+		return 0.3
+	}
+}
+
+// CollaborativeRecommender is an item-item collaborative filter: two books
+// are similar when the same users liked both, measured by the cosine of
+// their user vectors. The model is rebuilt from all interactions on a schedule.
+type CollaborativeRecommender struct {
+	catalog *Catalog
+	load    func() ([]models.BookInteraction, error)
+
+	mu    sync.RWMutex
+	model *cfModel
+}
+
+type cfModel struct {
+	// neighbors[book] are the most similar books, most similar first
+	neighbors map[int][]neighbor
+	// liked[user][book] is the user's interaction weight
+	liked map[int]map[int]float64
+	// popularity[book] is the summed interaction weight, used to break ties
+	popularity map[int]float64
+}
+
+type neighbor struct {
+	bookID     int
+	similarity float64
+}
+
+func NewCollaborativeRecommender(catalog *Catalog, load func() ([]models.BookInteraction, error)) *CollaborativeRecommender {
+	return &CollaborativeRecommender{catalog: catalog, load: load, model: &cfModel{}}
+}
+
+// Rebuild recomputes the similarity matrix from every interaction
+func (c *CollaborativeRecommender) Rebuild() error {
+	interactions, err := c.load()
+	if err != nil {
+		return err
+	}
+
+	model := buildCFModel(interactions)
+
+	c.mu.Lock()
+	c.model = model
+	c.mu.Unlock()
+
+	return nil
+}
+
+// StartRebuild rebuilds the model on every tick of interval
+func (c *CollaborativeRecommender) StartRebuild(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			start := time.Now()
+			if err := c.Rebuild(); err != nil {
+				log.Printf("Error rebuilding collaborative model: %v", err)
+				continue
+			}
+			log.Printf("Collaborative model rebuilt in %s", time.Since(start))
+		}
+	}()
+}
+
+func (c *CollaborativeRecommender) current() *cfModel {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.model
+}
+
+func buildCFModel(interactions []models.BookInteraction) *cfModel {
+	liked := map[int]map[int]float64{}
+	popularity := map[int]float64{}
+
+	for _, interaction := range interactions {
+		weight := InteractionWeight(interaction)
+		if weight <= 0 {
+			continue
+		}
+		if liked[interaction.UserID] == nil {
+			liked[interaction.UserID] = map[int]float64{}
+		}
+		if weight > liked[interaction.UserID][interaction.BookID] {
+			liked[interaction.UserID][interaction.BookID] = weight
+		}
+	}
+
+	norms := map[int]float64{}
+	dots := map[[2]int]float64{}
+
+	for _, books := range liked {
+		items := strongest(books, maxItemsPerUser)
+
+		for a, i := range items {
+			wi := books[i]
+			norms[i] += wi * wi
+			popularity[i] += wi
+
+			for _, j := range items[a+1:] {
+				key := [2]int{i, j}
+				if j < i {
+					key = [2]int{j, i}
+				}
+				dots[key] += wi * books[j]
+			}
+		}
+	}
+
+	neighbors := map[int][]neighbor{}
+	for key, dot := range dots {
+		similarity := dot / (math.Sqrt(norms[key[0]]) * math.Sqrt(norms[key[1]]))
+		neighbors[key[0]] = append(neighbors[key[0]], neighbor{key[1], similarity})
+		neighbors[key[1]] = append(neighbors[key[1]], neighbor{key[0], similarity})
+	}
+
+	for book, list := range neighbors {
+		sort.Slice(list, func(a, b int) bool {
+			if list[a].similarity != list[b].similarity {
+				return list[a].similarity > list[b].similarity
+			}
+			return list[a].bookID < list[b].bookID
+		})
+		if len(list) > maxNeighbors {
+			list = list[:maxNeighbors]
+		}
+		neighbors[book] = list
+	}
+
+	return &cfModel{neighbors: neighbors, liked: liked, popularity: popularity}
+}
+
+// strongest returns the ids of the n books with the highest weights
+func strongest(books map[int]float64, n int) []int {
+	ids := make([]int, 0, len(books))
+	for id := range books {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		if books[ids[a]] != books[ids[b]] {
+			return books[ids[a]] > books[ids[b]]
+		}
+		return ids[a] < ids[b]
+	})
+	if len(ids) > n {
+		ids = ids[:n]
+	}
+	return ids
+}
+
+// Similar returns the books most often liked by the users who liked bookID
+func (c *CollaborativeRecommender) Similar(bookID, limit int) []models.ScoredBook {
+	result := []models.ScoredBook{}
+	for _, n := range c.current().neighbors[bookID] {
+		if len(result) == limit {
+			break
+		}
+		book, ok := c.catalog.FindByID(n.bookID)
+		if !ok {
+			continue
+		}
+		result = append(result, models.ScoredBook{BookID: book.ID, Title: book.Title, Score: n.similarity})
+	}
+	return result
+}
+
+// RecommendForUser scores every neighbor of the user's books by the summed
+// similarity weighted by how much the user liked each of their books.
+// Extra seed books, such as the titles of a request, count as fully liked.
+func (c *CollaborativeRecommender) RecommendForUser(userID int, seeds []int, limit int) []models.ScoredBook {
+	result := c.score(userID, seeds)
+
+	books := []models.ScoredBook{}
+	for _, id := range result.ranked() {
+		if len(books) == limit {
+			break
+		}
+		book, ok := c.catalog.FindByID(id)
+		if !ok {
+			continue
+		}
+		books = append(books, models.ScoredBook{BookID: book.ID, Title: book.Title, Score: result.scores[id]})
+	}
+	return books
+}
+
+// cfResult is the collaborative scoring of the books for a user
+type cfResult struct {
+	model *cfModel
+	// liked are the books the user interacted with or named, by id
+	liked map[int]float64
+	// scores are the summed weighted similarities, by book id
+	scores map[int]float64
+	// because is the liked book that contributed the most to each score
+	because map[int]int
+}
+
+func (c *CollaborativeRecommender) score(userID int, seeds []int) cfResult {
+	model := c.current()
+
+	liked := map[int]float64{}
+	for bookID, weight := range model.liked[userID] {
+		liked[bookID] = weight
+	}
+	for _, bookID := range seeds {
+		liked[bookID] = 1
+	}
+
+	scores := map[int]float64{}
+	because := map[int]int{}
+	strongest := map[int]float64{}
+	for bookID, weight := range liked {
+		for _, n := range model.neighbors[bookID] {
+			if _, seen := liked[n.bookID]; seen {
+				continue
+			}
+			contribution := weight * n.similarity
+			scores[n.bookID] += contribution
+			if contribution > strongest[n.bookID] || (contribution == strongest[n.bookID] && bookID < because[n.bookID]) {
+				strongest[n.bookID] = contribution
+				because[n.bookID] = bookID
+			}
+		}
+	}
+
+	return cfResult{model: model, liked: liked, scores: scores, because: because}
+}
+
+// ranked returns the scored book ids, best first, more popular books first on ties
+func (r cfResult) ranked() []int {
+	ids := make([]int, 0, len(r.scores))
+	for id := range r.scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		if r.scores[ids[a]] != r.scores[ids[b]] {
+			return r.scores[ids[a]] > r.scores[ids[b]]
+		}
+		if r.model.popularity[ids[a]] != r.model.popularity[ids[b]] {
+			return r.model.popularity[ids[a]] > r.model.popularity[ids[b]]
+		}
+		return ids[a] < ids[b]
+	})
+	return ids
+}
+
+// reason names the liked book that contributed the most to bookID's score
+func (c *CollaborativeRecommender) reason(r cfResult, bookID int) models.Reason {
+	if book, ok := c.catalog.FindByID(r.because[bookID]); ok {
+		return readersAlsoLiked(book.Title)
+	}
+	return models.Reason{Code: ReasonReadersAlsoLiked, Message: "readers with similar taste liked this"}
+}
+
+func (c *CollaborativeRecommender) GetRecommendations(ctx context.Context, req models.RecommendationRequest) (*models.RecommendationResponse, error) {
+	var seeds []int
+	for _, title := range req.UserBookTitles {
+		if book, ok := c.catalog.FindByTitle(title); ok {
+			seeds = append(seeds, book.ID)
+		}
+	}
+
+	result := c.score(req.UserID, seeds)
+
+	response := &models.RecommendationResponse{RecommendedTitles: []string{}}
+	for _, id := range result.ranked() {
+		if len(response.RecommendedTitles) == DefaultRecommendationCount {
+			break
+		}
+		book, ok := c.catalog.FindByID(id)
+		if !ok {
+			continue
+		}
+		response.RecommendedTitles = append(response.RecommendedTitles, book.Title)
+		response.Recommendations = append(response.Recommendations, models.Recommendation{
+			BookID:  book.ID,
+			Title:   book.Title,
+			Score:   result.scores[id],
+			Reasons: []models.Reason{c.reason(result, id)},
+		})
+	}
+
+	return response, nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"book-recommendation-service/pkg/models"
+)
+
+func favorite(userID, bookID int) models.BookInteraction {
+	return models.BookInteraction{UserID: userID, BookID: bookID, Type: "favorite"}
+}
+
+func rating(userID, bookID int, score float64) models.BookInteraction {
+	return models.BookInteraction{UserID: userID, BookID: bookID, Type: "rate", Rating: score}
+}
+
+func TestInteractionWeight(t *testing.T) {
+	tests := []struct {
+		name        string
+		interaction models.BookInteraction
+		want        float64
+	}{
+		{"favorite", models.BookInteraction{Type: "favorite"}, 1},
+		{"purchase click", models.BookInteraction{Type: "purchase_click"}, 0.8},
+		{"comment", models.BookInteraction{Type: "comment"}, 0.5},
+		{"view", models.BookInteraction{Type: "view"}, 0.2},
+		{"rating of 5", models.BookInteraction{Type: "rate", Rating: 5}, 1},
+		{"rating of 4", models.BookInteraction{Type: "rating", Rating: 4}, 0.8},
+		{"rating of 3", models.BookInteraction{Type: "rate", Rating: 3}, 0.2},
+		{"rating of 2", models.BookInteraction{Type: "rate", Rating: 2}, 0},
+		{"unknown type", models.BookInteraction{Type: "share"}, 0.3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InteractionWeight(tt.interaction); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("InteractionWeight() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCFModel(t *testing.T) {
+	tests := []struct {
+		name         string
+		interactions []models.BookInteraction
+		// neighbors maps a book to its expected neighbors, most similar first
+		neighbors map[int][]neighbor
+	}{
+		{
+			name:      "no interactions",
+			neighbors: map[int][]neighbor{},
+		},
+		{
+			name:         "single books make no pairs",
+			interactions: []models.BookInteraction{favorite(1, 10), favorite(2, 20)},
+			neighbors:    map[int][]neighbor{},
+		},
+		{
+			name:         "always liked together",
+			interactions: []models.BookInteraction{favorite(1, 10), favorite(1, 20), favorite(2, 10), favorite(2, 20)},
+			neighbors: map[int][]neighbor{
+				10: {{20, 1}},
+				20: {{10, 1}},
+			},
+		},
+		{
+			name:         "shared with one other book each",
+			interactions: []models.BookInteraction{favorite(1, 10), favorite(1, 20), favorite(2, 10), favorite(2, 30)},
+			neighbors: map[int][]neighbor{
+				10: {{20, 1 / math.Sqrt2}, {30, 1 / math.Sqrt2}},
+				20: {{10, 1 / math.Sqrt2}},
+				30: {{10, 1 / math.Sqrt2}},
+			},
+		},
+		{
+			name:         "low ratings are not likes",
+			interactions: []models.BookInteraction{favorite(1, 10), rating(1, 20, 2), favorite(2, 10), rating(2, 20, 1)},
+			neighbors:    map[int][]neighbor{},
+		},
+		{
+			name: "a user's strongest interaction with a book counts",
+			interactions: []models.BookInteraction{
+				{UserID: 1, BookID: 10, Type: "view"}, favorite(1, 10), favorite(1, 20),
+				favorite(2, 10), {UserID: 2, BookID: 30, Type: "view"},
+			},
+			neighbors: map[int][]neighbor{
+				// 10 is (1, 1), 20 is (1, 0) and 30 is (0, 0.2) over users 1 and 2
+				10: {{20, 1 / math.Sqrt2}, {30, 1 / math.Sqrt2}},
+				20: {{10, 1 / math.Sqrt2}},
+				30: {{10, 1 / math.Sqrt2}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := buildCFModel(tt.interactions)
+
+			if len(model.neighbors) != len(tt.neighbors) {
+				t.Fatalf("got neighbors for %d books, want %d: %v", len(model.neighbors), len(tt.neighbors), model.neighbors)
+			}
+			for book, want := range tt.neighbors {
+				got := model.neighbors[book]
+				if len(got) != len(want) {
+					t.Fatalf("neighbors[%d] = %v, want %v", book, got, want)
+				}
+				for i := range want {
+					if got[i].bookID != want[i].bookID || math.Abs(got[i].similarity-want[i].similarity) > 1e-9 {
+						t.Errorf("neighbors[%d] = %v, want %v", book, got, want)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBuildCFModelBounds(t *testing.T) {
+	var interactions []models.BookInteraction
+	for book := 1; book <= maxItemsPerUser+10; book++ {
+		interactions = append(interactions, favorite(1, book))
+	}
+
+	model := buildCFModel(interactions)
+
+	if got := len(model.popularity); got != maxItemsPerUser {
+		t.Errorf("a user contributed %d books, want at most %d", got, maxItemsPerUser)
+	}
+	for book, list := range model.neighbors {
+		if len(list) > maxNeighbors {
+			t.Fatalf("book %d has %d neighbors, want at most %d", book, len(list), maxNeighbors)
+		}
+	}
+	if got := len(model.neighbors[1]); got != maxNeighbors {
+		t.Errorf("book 1 has %d neighbors, want %d", got, maxNeighbors)
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+
+	"book-recommendation-service/pkg/models"
+)
+
+// ContentRecommender is the native port of model.py: books are one-hot
+// encoded on their Main Genre, Sub Genre, Type and Author, the user profile
+// is the average of the vectors of the books they named, weighted by their
+// preferences, and books are ranked by cosine similarity to the profile.
+// model.py additionally ZCA-whitens the features; that needs a dense SVD over
+// every distinct author and is left out.
+type ContentRecommender struct {
+	catalog *Catalog
+	prefs   PreferenceSource
+}
+
+func NewContentRecommender(catalog *Catalog, prefs PreferenceSource) *ContentRecommender {
+	return &ContentRecommender{catalog: catalog, prefs: prefs}
+}
+
+func (c *ContentRecommender) GetRecommendations(ctx context.Context, req models.RecommendationRequest) (*models.RecommendationResponse, error) {
+	result := c.score(req.UserBookTitles, c.userPreferences(req.UserID))
+
+	response := &models.RecommendationResponse{RecommendedTitles: []string{}}
+	seen := result.likedTitles()
+
+	for _, scored := range result.scores {
+		if len(response.RecommendedTitles) == DefaultRecommendationCount {
+			break
+		}
+		book := result.snapshot.books[scored.index]
+		if seen[normalizeTitle(book.Title)] {
+			continue
+		}
+		seen[normalizeTitle(book.Title)] = true
+
+		response.RecommendedTitles = append(response.RecommendedTitles, book.Title)
+		response.Recommendations = append(response.Recommendations, models.Recommendation{
+			BookID:  book.ID,
+			Title:   book.Title,
+			Score:   scored.score,
+			Reasons: []models.Reason{result.reason(scored.index)},
+		})
+	}
+
+	return response, nil
+}
+
+// contentResult is the content-based ranking of the catalog for a request
+type contentResult struct {
+	snapshot *catalogSnapshot
+	prefs    models.UserPreferencesMap
+	// liked are the indexes of the books named in the request
+	liked map[int]bool
+	// scores rank the other books, best first
+	scores []scoredBook
+}
+
+func (c *ContentRecommender) score(titles []string, prefs models.UserPreferencesMap) contentResult {
+	snapshot := c.catalog.current()
+
+	liked := map[int]bool{}
+	profile := map[int]float64{}
+
+	for _, title := range titles {
+		i, ok := snapshot.byTitle[normalizeTitle(title)]
+		if !ok || liked[i] {
+			continue
+		}
+		liked[i] = true
+
+		weight := explicitWeight(snapshot.books[i], prefs)
+		for _, feature := range snapshot.features[i] {
+			profile[feature] += weight
+		}
+	}
+
+	// without any known book, the preferences alone make the profile
+	if len(liked) == 0 {
+		for attribute, values := range prefs {
+			for value, weight := range values {
+				if feature, ok := snapshot.featureIDs[attribute][value]; ok {
+					profile[feature] += weight
+				}
+			}
+		}
+	}
+
+	return contentResult{
+		snapshot: snapshot,
+		prefs:    prefs,
+		liked:    liked,
+		scores:   cosineScores(snapshot, profile, liked),
+	}
+}
+
+// likedTitles returns the normalized titles of the liked books
+func (r contentResult) likedTitles() map[string]bool {
+	titles := map[string]bool{}
+	for i := range r.liked {
+		titles[normalizeTitle(r.snapshot.books[i].Title)] = true
+	}
+	return titles
+}
+
+// reason explains the score of books[i]: the liked book sharing the most
+// features with it or, when the request named none, the preference it matches
+func (r contentResult) reason(i int) models.Reason {
+	book := r.snapshot.books[i]
+
+	best, bestShared := -1, 0
+	for j := range r.liked {
+		shared := sharedFeatures(r.snapshot.features[i], r.snapshot.features[j])
+		if shared > bestShared || (shared == bestShared && shared > 0 && j < best) {
+			best, bestShared = j, shared
+		}
+	}
+	if best >= 0 {
+		return becauseYouLiked(r.snapshot.books[best].Title)
+	}
+
+	value, _, _ := strongestPreference(book, r.prefs)
+	return matchesPreference(value)
+}
+
+func sharedFeatures(a, b []int) int {
+	shared := 0
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				shared++
+			}
+		}
+	}
+	return shared
+}
+
+// userPreferences returns the user's preferences, or none when they have not
+// been learned yet
+func (c *ContentRecommender) userPreferences(userID int) models.UserPreferencesMap {
+	if c.prefs == nil || userID == 0 {
+		return models.UserPreferencesMap{}
+	}
+
+	raw, err := c.prefs.GetUserPreferences(userID)
+	if err != nil {
+		log.Printf("Using no preferences for user %d: %v", userID, err)
+		return models.UserPreferencesMap{}
+	}
+
+	return c.catalog.canonicalPreferences(ConvertToPreferencesMap(raw))
+}
+
+// explicitWeight multiplies the user's weights for the book's attribute
+// values, like compute_explicit_weight in model.py
+func explicitWeight(book models.Book, prefs models.UserPreferencesMap) float64 {
+	weight := 1.0
+	for _, attribute := range Attributes {
+		if w, ok := prefs[attribute][AttributeValue(book, attribute)]; ok {
+			weight *= w
+		}
+	}
+	return weight
+}
+
+type scoredBook struct {
+	index int
+	score float64
+}
+
+// cosineScores ranks every book outside exclude by cosine similarity to the
+// profile. Book vectors are one-hot, so a dot product is a sum over the
+// book's features.
+func cosineScores(snapshot *catalogSnapshot, profile map[int]float64, exclude map[int]bool) []scoredBook {
+	var norm float64
+	for _, weight := range profile {
+		norm += weight * weight
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		return nil
+	}
+
+	scores := []scoredBook{}
+	for i, features := range snapshot.features {
+		if exclude[i] || len(features) == 0 {
+			continue
+		}
+
+		var dot float64
+		for _, feature := range features {
+			dot += profile[feature]
+		}
+		if dot <= 0 {
+			continue
+		}
+
+		scores = append(scores, scoredBook{index: i, score: dot / (norm * math.Sqrt(float64(len(features))))})
+	}
+
+	sort.SliceStable(scores, func(a, b int) bool {
+		return scores[a].score > scores[b].score
+	})
+
+	return scores
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"os"
+
+	"book-recommendation-service/pkg/models"
+)
+
+// Experiment splits users between variants of the recommender, read from
+// EXPERIMENT_FILE, e.g.
+//
+//	{
+//	  "name": "blend-weights",
+//	  "variants": [
+//	    {"name": "control", "traffic": 50},
+//	    {"name": "more-collaborative", "traffic": 50, "recommender": "hybrid",
+//	     "weights": {"content": 0.25, "collaborative": 0.5, "popularity": 0.1, "preferences": 0.15}}
+//	  ]
+//	}
+type Experiment struct {
+	Name     string    `json:"name"`
+	Variants []Variant `json:"variants"`
+}
+
+// Variant is one arm of an experiment. Recommender and Weights default to the
+// service's RECOMMENDER and WEIGHT_* settings.
+type Variant struct {
+	Name string `json:"name"`
+	// Traffic is the variant's share of users, relative to the other variants
+	Traffic     int            `json:"traffic"`
+	Recommender string         `json:"recommender,omitempty"`
+	Weights     *HybridWeights `json:"weights,omitempty"`
+}
+
+// LoadExperiment reads and validates an experiment file
+func LoadExperiment(path string) (*Experiment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+
+	var experiment Experiment
+	if err := decoder.Decode(&experiment); err != nil {
+		return nil, fmt.Errorf("reading %s: %v", path, err)
+	}
+
+	if experiment.Name == "" {
+		return nil, fmt.Errorf("%s: experiment has no name", path)
+	}
+	if len(experiment.Variants) == 0 {
+		return nil, fmt.Errorf("%s: experiment has no variants", path)
+	}
+
+	names := map[string]bool{}
+	for _, variant := range experiment.Variants {
+		if variant.Name == "" || names[variant.Name] {
+			return nil, fmt.Errorf("%s: variant names must be unique and not empty", path)
+		}
+		if variant.Traffic <= 0 {
+			return nil, fmt.Errorf("%s: variant %q needs positive traffic", path, variant.Name)
+		}
+		names[variant.Name] = true
+	}
+
+	return &experiment, nil
+}
+
+// Assign buckets a user into a variant by a hash of the experiment name and
+// user id, so a user keeps their variant across requests and restarts, and
+// buckets of different experiments are independent
+func (e *Experiment) Assign(userID int) Variant {
+	total := 0
+	for _, variant := range e.Variants {
+		total += variant.Traffic
+	}
+
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%d", e.Name, userID)
+	bucket := int(h.Sum32() % uint32(total))
+
+	for _, variant := range e.Variants {
+		if bucket < variant.Traffic {
+			return variant
+		}
+		bucket -= variant.Traffic
+	}
+	return e.Variants[len(e.Variants)-1]
+}
+
+// ExperimentStore logs what users of each variant were shown and clicked,
+// see db.Repository
+type ExperimentStore interface {
+	LogImpressions(assignment models.ExperimentAssignment, userID int, bookIDs []int) error
+	LogClick(assignment models.ExperimentAssignment, userID, bookID int) error
+	GetExperimentCounts(experiment string) ([]models.VariantCounts, error)
+}
+
+// ExperimentRecommender serves each user from the recommender of their
+// variant and logs every recommended book as an impression
+type ExperimentRecommender struct {
+	experiment *Experiment
+	variants   map[string]Recommender
+	store      ExperimentStore
+}
+
+// NewExperimentRecommender builds the recommender of every variant with build
+func NewExperimentRecommender(experiment *Experiment, store ExperimentStore, build func(Variant) (Recommender, error)) (*ExperimentRecommender, error) {
+	variants := map[string]Recommender{}
+	for _, variant := range experiment.Variants {
+		recommender, err := build(variant)
+		if err != nil {
+			return nil, fmt.Errorf("variant %q: %v", variant.Name, err)
+		}
+		variants[variant.Name] = recommender
+	}
+
+	return &ExperimentRecommender{experiment: experiment, variants: variants, store: store}, nil
+}
+
+func (e *ExperimentRecommender) Experiment() *Experiment {
+	return e.experiment
+}
+
+func (e *ExperimentRecommender) Assign(userID int) models.ExperimentAssignment {
+	return models.ExperimentAssignment{Experiment: e.experiment.Name, Variant: e.experiment.Assign(userID).Name}
+}
+
+func (e *ExperimentRecommender) GetRecommendations(ctx context.Context, req models.RecommendationRequest) (*models.RecommendationResponse, error) {
+	assignment := e.Assign(req.UserID)
+
+	response, err := e.variants[assignment.Variant].GetRecommendations(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	response.Experiment = &assignment
+
+	var bookIDs []int
+	for _, recommendation := range response.Recommendations {
+		if recommendation.BookID > 0 {
+			bookIDs = append(bookIDs, recommendation.BookID)
+		}
+	}
+
+	// clicks need a user, so impressions of anonymous requests would only
+	// dilute the click-through rate
+	if req.UserID > 0 && len(bookIDs) > 0 {
+		if err := e.store.LogImpressions(assignment, req.UserID, bookIDs); err != nil {
+			log.Printf("Error logging impressions: %v", err)
+		}
+	}
+
+	return response, nil
+}
+
+// LogClick logs a click on a recommended book for the user's variant
+func (e *ExperimentRecommender) LogClick(click models.ExperimentClick) (models.ExperimentAssignment, error) {
+	if click.UserID <= 0 || click.BookID <= 0 {
+		return models.ExperimentAssignment{}, errors.New("user_id and book_id must be provided")
+	}
+
+	assignment := e.Assign(click.UserID)
+	return assignment, e.store.LogClick(assignment, click.UserID, click.BookID)
+}
+
+// confidenceZ is the normal quantile of a 95% confidence interval
+const confidenceZ = 1.96
+
+// ExperimentResults computes the click-through rate of every variant, clicks
+// per impression, with its Wilson score interval. Clicks are only logged on
+// impressions, so the rate is at most 1. The same reader decides on all of
+// their impressions, so the interval is taken over the number of independent
+// impressions they are worth, see effectiveImpressions.
+func ExperimentResults(counts []models.VariantCounts) []models.VariantResult {
+	results := make([]models.VariantResult, 0, len(counts))
+	for _, c := range counts {
+		result := models.VariantResult{VariantCounts: c}
+		if c.Impressions > 0 {
+			result.CTR = float64(c.Clicks) / float64(c.Impressions)
+			result.CILow, result.CIHigh = wilsonInterval(result.CTR, effectiveImpressions(result.CTR, c))
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// effectiveImpressions is the number of independent impressions a variant's
+// are worth: the binomial variance of the rate p divided by its variance with
+// users as clusters (the delta method for a ratio of means). It lies between
+// the number of users, when each user clicks all or none of their books, and
+// the number of impressions, when clicks are independent. Without two users or
+// any spread in p to estimate from, the number of users is the cautious pick.
+func effectiveImpressions(p float64, c models.VariantCounts) float64 {
+	if len(c.PerUser) < 2 || p == 0 || p == 1 {
+		return math.Max(float64(c.Users), 1)
+	}
+
+	users := float64(len(c.PerUser))
+	impressions := float64(c.Impressions)
+
+	mean := impressions / users
+	var squares float64
+	for _, u := range c.PerUser {
+		d := float64(u.Clicks) - p*float64(u.Impressions)
+		squares += d * d
+	}
+	variance := squares / (users * (users - 1) * mean * mean)
+	if variance == 0 {
+		return impressions
+	}
+
+	return math.Max(users, math.Min(impressions, p*(1-p)/variance))
+}
+
+// wilsonInterval is the Wilson score interval of a rate p over n trials,
+// which stays within [0, 1] and is sound for few clicks
+func wilsonInterval(p, n float64) (low, high float64) {
+	z2 := confidenceZ * confidenceZ
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	margin := confidenceZ * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / (1 + z2/n)
+	return math.Max(center-margin, 0), math.Min(center+margin, 1)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"book-recommendation-service/pkg/models"
+)
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		name      string
+		p, n      float64
+		low, high float64
+	}{
+		{"half of 100", 0.5, 100, 0.40383, 0.59617},
+		{"none of 10", 0, 10, 0, 0.27754},
+		{"all of 10", 1, 10, 0.72246, 1},
+		{"a tenth of 1000", 0.1, 1000, 0.08291, 0.12015},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			low, high := wilsonInterval(tt.p, tt.n)
+			if math.Abs(low-tt.low) > 1e-4 || math.Abs(high-tt.high) > 1e-4 {
+				t.Errorf("wilsonInterval(%v, %v) = (%.5f, %.5f), want (%.5f, %.5f)", tt.p, tt.n, low, high, tt.low, tt.high)
+			}
+		})
+	}
+}
+
+func TestAssign(t *testing.T) {
+	experiment := &Experiment{
+		Name: "blend-weights",
+		Variants: []Variant{
+			{Name: "control", Traffic: 75},
+			{Name: "treatment", Traffic: 25},
+		},
+	}
+
+	const users = 20000
+	counts := map[string]int{}
+	for userID := 1; userID <= users; userID++ {
+		variant := experiment.Assign(userID)
+		if again := experiment.Assign(userID); again.Name != variant.Name {
+			t.Fatalf("user %d was assigned %q, then %q", userID, variant.Name, again.Name)
+		}
+		counts[variant.Name]++
+	}
+
+	for _, variant := range experiment.Variants {
+		want := users * variant.Traffic / 100
+		if got := counts[variant.Name]; math.Abs(float64(got-want)) > 0.02*users {
+			t.Errorf("variant %q got %d users, want about %d", variant.Name, got, want)
+		}
+	}
+
+	// another experiment buckets the same users independently
+	other := &Experiment{Name: "other", Variants: experiment.Variants}
+	same := 0
+	for userID := 1; userID <= users; userID++ {
+		if experiment.Assign(userID).Name == other.Assign(userID).Name {
+			same++
+		}
+	}
+	// independent 75/25 splits agree for 0.75² + 0.25² of the users
+	if want := 0.625 * users; math.Abs(float64(same)-want) > 0.02*users {
+		t.Errorf("%d users share their variant across experiments, want about %.0f", same, want)
+	}
+}
+
+func TestAssignSingleVariant(t *testing.T) {
+	experiment := &Experiment{Name: "only", Variants: []Variant{{Name: "control", Traffic: 1}}}
+	for userID := 0; userID < 100; userID++ {
+		if got := experiment.Assign(userID).Name; got != "control" {
+			t.Fatalf("user %d was assigned %q", userID, got)
+		}
+	}
+}
+
+func perUser(impressions, clicks []int) models.VariantCounts {
+	c := models.VariantCounts{Variant: "v"}
+	for i := range impressions {
+		c.Users++
+		c.Impressions += impressions[i]
+		c.Clicks += clicks[i]
+		c.PerUser = append(c.PerUser, models.UserCounts{Impressions: impressions[i], Clicks: clicks[i]})
+	}
+	return c
+}
+
+func TestEffectiveImpressions(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts models.VariantCounts
+		want   float64
+	}{
+		// every user clicks exactly the rate, as independent clicks would on average
+		{"no spread between users", perUser([]int{10, 10, 10, 10}, []int{2, 2, 2, 2}), 40},
+		{"users click all or nothing", perUser([]int{10, 10, 10, 10}, []int{10, 0, 0, 0}), 4},
+		{"some spread", perUser([]int{10, 10, 10, 10}, []int{4, 2, 1, 1}), 32},
+		{"no clicks", perUser([]int{10, 10}, []int{0, 0}), 2},
+		{"a single user", perUser([]int{10}, []int{3}), 1},
+		{"no per user counts", models.VariantCounts{Users: 3, Impressions: 30, Clicks: 3}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := float64(tt.counts.Clicks) / float64(tt.counts.Impressions)
+			if got := effectiveImpressions(p, tt.counts); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("effectiveImpressions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExperimentResults(t *testing.T) {
+	counts := []models.VariantCounts{
+		perUser([]int{10, 10, 10, 10}, []int{4, 2, 1, 1}),
+		{Variant: "unseen"},
+	}
+
+	results := ExperimentResults(counts)
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	r := results[0]
+	if r.CTR != 0.2 {
+		t.Errorf("CTR = %v, want 0.2", r.CTR)
+	}
+	low, high := wilsonInterval(0.2, 32)
+	if math.Abs(r.CILow-low) > 1e-9 || math.Abs(r.CIHigh-high) > 1e-9 {
+		t.Errorf("interval = (%v, %v), want (%v, %v)", r.CILow, r.CIHigh, low, high)
+	}
+	if naiveLow, naiveHigh := wilsonInterval(0.2, 40); r.CIHigh-r.CILow <= naiveHigh-naiveLow {
+		t.Errorf("clustered interval (%v, %v) is not wider than the independent one (%v, %v)", r.CILow, r.CIHigh, naiveLow, naiveHigh)
+	}
+
+	if results[1].CTR != 0 || results[1].CILow != 0 || results[1].CIHigh != 0 {
+		t.Errorf("variant without impressions got %+v", results[1])
+	}
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"book-recommendation-service/pkg/models"
+)
+
+// HybridWeights are the shares of each signal in a hybrid recommendation.
+// They need not sum to one; a zero weight turns the signal off.
+type HybridWeights struct {
+	Content       float64 `json:"content"`
+	Collaborative float64 `json:"collaborative"`
+	// Popularity is how much the genres, types and authors liked by
+	// everyone count, from global_preferences
+	Popularity float64 `json:"popularity"`
+	// Preferences is how much the user's own learned preferences count
+	Preferences float64 `json:"preferences"`
+}
+
+// reasonShare is the share of a book's score a signal must contribute for its
+// reason to be listed. The strongest signal is always listed.
+const reasonShare = 0.25
+
+// HybridRecommender blends the content-based and collaborative scores with
+// the popularity and user preferences of each book's attributes. Every signal
+// is scaled to [0, 1] over the candidates before it is weighted.
+type HybridRecommender struct {
+	content *ContentRecommender
+	cf      *CollaborativeRecommender
+	prefs   PreferenceSource
+	weights HybridWeights
+}
+
+func NewHybridRecommender(catalog *Catalog, prefs PreferenceSource, cf *CollaborativeRecommender, weights HybridWeights) *HybridRecommender {
+	return &HybridRecommender{
+		content: NewContentRecommender(catalog, prefs),
+		cf:      cf,
+		prefs:   prefs,
+		weights: weights,
+	}
+}
+
+// signal is one weighted input of the blend
+type signal struct {
+	weight float64
+	// scores are by book index in the catalog snapshot
+	scores map[int]float64
+	reason func(i int) models.Reason
+}
+
+func (h *HybridRecommender) GetRecommendations(ctx context.Context, req models.RecommendationRequest) (*models.RecommendationResponse, error) {
+	prefs := h.content.userPreferences(req.UserID)
+	content := h.content.score(req.UserBookTitles, prefs)
+	snapshot := content.snapshot
+
+	exclude := content.likedTitles()
+	signals := []signal{}
+
+	if h.weights.Content > 0 {
+		scores := map[int]float64{}
+		for _, scored := range content.scores {
+			scores[scored.index] = scored.score
+		}
+		signals = append(signals, signal{h.weights.Content, scores, content.reason})
+	}
+
+	if h.weights.Collaborative > 0 && h.cf != nil {
+		var seeds []int
+		for i := range content.liked {
+			seeds = append(seeds, snapshot.books[i].ID)
+		}
+		cf := h.cf.score(req.UserID, seeds)
+
+		for bookID := range cf.liked {
+			if i, ok := snapshot.byID[bookID]; ok {
+				exclude[normalizeTitle(snapshot.books[i].Title)] = true
+			}
+		}
+
+		scores := map[int]float64{}
+		for bookID, score := range cf.scores {
+			if i, ok := snapshot.byID[bookID]; ok {
+				scores[i] = score
+			}
+		}
+		signals = append(signals, signal{h.weights.Collaborative, scores, func(i int) models.Reason {
+			return h.cf.reason(cf, snapshot.books[i].ID)
+		}})
+	}
+
+	if h.weights.Popularity > 0 {
+		globals := h.globalPreferences()
+		scores := attributeScores(snapshot, globals)
+		signals = append(signals, signal{h.weights.Popularity, scores, func(i int) models.Reason {
+			return popularIn(popularGenre(snapshot.books[i], globals))
+		}})
+	}
+
+	if h.weights.Preferences > 0 && len(prefs) > 0 {
+		scores := attributeScores(snapshot, prefs)
+		signals = append(signals, signal{h.weights.Preferences, scores, func(i int) models.Reason {
+			value, _, _ := strongestPreference(snapshot.books[i], prefs)
+			return matchesPreference(value)
+		}})
+	}
+
+	var total float64
+	for _, s := range signals {
+		total += s.weight
+	}
+
+	// contributions[i][k] is the weighted, scaled score of signal k for book i
+	contributions := map[int][]float64{}
+	for k, s := range signals {
+		var max float64
+		for _, score := range s.scores {
+			if score > max {
+				max = score
+			}
+		}
+		if max <= 0 {
+			continue
+		}
+
+		for i, score := range s.scores {
+			if score <= 0 || exclude[normalizeTitle(snapshot.books[i].Title)] {
+				continue
+			}
+			if contributions[i] == nil {
+				contributions[i] = make([]float64, len(signals))
+			}
+			contributions[i][k] = s.weight / total * score / max
+		}
+	}
+
+	ranked := make([]scoredBook, 0, len(contributions))
+	for i, parts := range contributions {
+		var score float64
+		for _, part := range parts {
+			score += part
+		}
+		ranked = append(ranked, scoredBook{index: i, score: score})
+	}
+	sort.Slice(ranked, func(a, b int) bool {
+		if ranked[a].score != ranked[b].score {
+			return ranked[a].score > ranked[b].score
+		}
+		return snapshot.books[ranked[a].index].ID < snapshot.books[ranked[b].index].ID
+	})
+
+	response := &models.RecommendationResponse{RecommendedTitles: []string{}}
+	seen := map[string]bool{}
+
+	for _, scored := range ranked {
+		if len(response.RecommendedTitles) == DefaultRecommendationCount {
+			break
+		}
+		book := snapshot.books[scored.index]
+		if seen[normalizeTitle(book.Title)] {
+			continue
+		}
+		seen[normalizeTitle(book.Title)] = true
+
+		response.RecommendedTitles = append(response.RecommendedTitles, book.Title)
+		response.Recommendations = append(response.Recommendations, models.Recommendation{
+			BookID:  book.ID,
+			Title:   book.Title,
+			Score:   scored.score,
+			Reasons: reasons(signals, contributions[scored.index], scored.index, scored.score),
+		})
+	}
+
+	return response, nil
+}
+
+// reasons lists the reasons of the signals that contributed enough to the
+// score, strongest first
+func reasons(signals []signal, parts []float64, i int, score float64) []models.Reason {
+	order := make([]int, len(parts))
+	for k := range order {
+		order[k] = k
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return parts[order[a]] > parts[order[b]]
+	})
+
+	result := []models.Reason{}
+	for n, k := range order {
+		if parts[k] <= 0 || (n > 0 && parts[k] < reasonShare*score) {
+			break
+		}
+		result = append(result, signals[k].reason(i))
+	}
+	return result
+}
+
+// globalPreferences returns the preferences of all users, or none when they
+// cannot be loaded
+func (h *HybridRecommender) globalPreferences() models.UserPreferencesMap {
+	if h.prefs == nil {
+		return models.UserPreferencesMap{}
+	}
+
+	globals, err := h.prefs.GetGlobalPreferences()
+	if err != nil {
+		log.Printf("Using no global preferences: %v", err)
+		return models.UserPreferencesMap{}
+	}
+	return h.content.catalog.canonicalPreferences(globals)
+}
+
+// attributeScores scores every book by the mean weight of its attribute values
+func attributeScores(snapshot *catalogSnapshot, weights models.UserPreferencesMap) map[int]float64 {
+	scores := map[int]float64{}
+	if len(weights) == 0 {
+		return scores
+	}
+
+	for i, book := range snapshot.books {
+		var sum float64
+		for _, attribute := range Attributes {
+			if w := weights[attribute][AttributeValue(book, attribute)]; w > 0 {
+				sum += w
+			}
+		}
+		if sum > 0 {
+			scores[i] = sum / float64(len(Attributes))
+		}
+	}
+	return scores
+}
+
+// popularGenre returns the book's sub-genre, or its main genre when that is
+// the more popular of the two
+func popularGenre(book models.Book, globals models.UserPreferencesMap) string {
+	if book.SubGenre == "" || globals["Main Genre"][book.MainGenre] > globals["Sub Genre"][book.SubGenre] {
+		return book.MainGenre
+	}
+	return book.SubGenre
+}
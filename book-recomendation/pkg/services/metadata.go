@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"book-recommendation-service/pkg/models"
+)
+
+// BookSource loads books from the books table shared with book-service, see db.Repository
+type BookSource interface {
+	GetBooksByIDs(ids []int) ([]models.Book, error)
+}
+
+// metadataRecommender accepts book ids as well as titles and returns every
+// recommendation with the book's current metadata
+type metadataRecommender struct {
+	next    Recommender
+	catalog *Catalog
+	books   BookSource
+}
+
+// WithMetadata wraps a recommender so requests may name books by id and
+// responses carry each book's id, author, genres and rating. Recommendations
+// that only come as titles, from the Python model service, are matched to the
+// catalog ignoring case and punctuation; titles matching no book are dropped.
+func WithMetadata(next Recommender, catalog *Catalog, books BookSource) Recommender {
+	return &metadataRecommender{next: next, catalog: catalog, books: books}
+}
+
+func (m *metadataRecommender) GetRecommendations(ctx context.Context, req models.RecommendationRequest) (*models.RecommendationResponse, error) {
+	req.UserBookTitles = m.titles(req)
+
+	response, err := m.next.GetRecommendations(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	items := response.Recommendations
+	if len(items) == 0 {
+		items = m.fromTitles(response.RecommendedTitles)
+	}
+
+	return m.hydrate(items), nil
+}
+
+// titles returns the request's titles followed by those of its book ids,
+// which every recommender and the model service understand
+func (m *metadataRecommender) titles(req models.RecommendationRequest) []string {
+	titles := append([]string{}, req.UserBookTitles...)
+	named := map[string]bool{}
+	for _, title := range titles {
+		named[normalizeTitle(title)] = true
+	}
+
+	for _, id := range req.BookIDs {
+		book, ok := m.catalog.FindByID(id)
+		if !ok || named[normalizeTitle(book.Title)] {
+			continue
+		}
+		named[normalizeTitle(book.Title)] = true
+		titles = append(titles, book.Title)
+	}
+
+	return titles
+}
+
+// fromTitles matches ranked titles to catalog books. The model service gives
+// no scores, so the books are scored by rank, from 1 down.
+func (m *metadataRecommender) fromTitles(titles []string) []models.Recommendation {
+	items := []models.Recommendation{}
+	for rank, title := range titles {
+		book, ok := m.catalog.FindByTitle(title)
+		if !ok {
+			log.Printf("Dropping recommended title missing from the catalog: %q", title)
+			continue
+		}
+		items = append(items, models.Recommendation{
+			BookID:  book.ID,
+			Title:   book.Title,
+			Score:   1 - float64(rank)/float64(len(titles)),
+			Reasons: []models.Reason{},
+		})
+	}
+	return items
+}
+
+// hydrate fills in the metadata of the recommended books from the books
+// table, or from the catalog when the table cannot be read, and drops books
+// that no longer exist
+func (m *metadataRecommender) hydrate(items []models.Recommendation) *models.RecommendationResponse {
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.BookID)
+	}
+
+	find := m.catalog.FindByID
+	if m.books != nil && len(ids) > 0 {
+		books, err := m.books.GetBooksByIDs(ids)
+		if err != nil {
+			log.Printf("Using catalog metadata for recommendations: %v", err)
+		} else {
+			byID := make(map[int]models.Book, len(books))
+			for _, book := range books {
+				byID[book.ID] = book
+			}
+			find = func(id int) (models.Book, bool) {
+				book, ok := byID[id]
+				return book, ok
+			}
+		}
+	}
+
+	response := &models.RecommendationResponse{
+		RecommendedTitles: []string{},
+		Recommendations:   []models.Recommendation{},
+	}
+
+	for _, item := range items {
+		book, ok := find(item.BookID)
+		if !ok {
+			continue
+		}
+
+		item.Title = book.Title
+		item.Author = book.Author
+		item.MainGenre = book.MainGenre
+		item.SubGenre = book.SubGenre
+		item.Type = book.Type
+		item.Rating = book.Rating
+		item.RatingsCount = book.RatingsCount
+		if item.Reasons == nil {
+			item.Reasons = []models.Reason{}
+		}
+
+		response.RecommendedTitles = append(response.RecommendedTitles, item.Title)
+		response.Recommendations = append(response.Recommendations, item)
+	}
+
+	return response
+}
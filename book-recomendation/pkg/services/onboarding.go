@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"book-recommendation-service/pkg/models"
+)
+
+const (
+	// onboardingSubGenres and onboardingSamples are how many sub-genres each
+	// onboarding genre shows and how many books each sub-genre shows
+	onboardingSubGenres = 3
+	onboardingSamples   = 3
+)
+
+// Weights seeded from the onboarding questionnaire. A picked genre or author
+// is a stronger signal than the attributes of a picked book.
+const (
+	onboardingGenreWeight  = 0.8
+	onboardingAuthorWeight = 0.8
+	onboardingBookWeight   = 0.6
+)
+
+var ErrEmptySelection = errors.New("select at least one genre, sub-genre, author or book")
+
+// OnboardingGenres picks up to limit main genres for new users to choose
+// from, the most popular first, each with its most popular sub-genres and
+// their best rated books
+func OnboardingGenres(catalog *Catalog, globals models.UserPreferencesMap, limit int) []models.OnboardingGenre {
+	snapshot := catalog.current()
+
+	books := map[string]map[string][]int{}
+	for i, book := range snapshot.books {
+		if book.MainGenre == "" || book.SubGenre == "" {
+			continue
+		}
+		if books[book.MainGenre] == nil {
+			books[book.MainGenre] = map[string][]int{}
+		}
+		books[book.MainGenre][book.SubGenre] = append(books[book.MainGenre][book.SubGenre], i)
+	}
+
+	type rankedSubGenre struct {
+		genre models.OnboardingSubGenre
+		score float64
+	}
+	type rankedGenre struct {
+		genre models.OnboardingGenre
+		score float64
+	}
+
+	genres := []rankedGenre{}
+	for mainGenre, subGenres := range books {
+		ranked := []rankedSubGenre{}
+		for subGenre, indexes := range subGenres {
+			sort.SliceStable(indexes, func(a, b int) bool {
+				return quality(snapshot.books[indexes[a]]) > quality(snapshot.books[indexes[b]])
+			})
+
+			sub := rankedSubGenre{
+				genre: models.OnboardingSubGenre{SubGenre: subGenre, Books: []models.Book{}},
+				score: globals["Sub Genre"][subGenre],
+			}
+			seen := map[string]bool{}
+			for _, i := range indexes {
+				if len(sub.genre.Books) == onboardingSamples {
+					break
+				}
+				if seen[normalizeTitle(snapshot.books[i].Title)] {
+					continue
+				}
+				seen[normalizeTitle(snapshot.books[i].Title)] = true
+				sub.genre.Books = append(sub.genre.Books, snapshot.books[i])
+				sub.score += quality(snapshot.books[i]) / onboardingSamples
+			}
+			ranked = append(ranked, sub)
+		}
+
+		sort.Slice(ranked, func(a, b int) bool {
+			if ranked[a].score != ranked[b].score {
+				return ranked[a].score > ranked[b].score
+			}
+			return ranked[a].genre.SubGenre < ranked[b].genre.SubGenre
+		})
+		if len(ranked) > onboardingSubGenres {
+			ranked = ranked[:onboardingSubGenres]
+		}
+
+		genre := rankedGenre{
+			genre: models.OnboardingGenre{MainGenre: mainGenre},
+			score: globals["Main Genre"][mainGenre] + ranked[0].score,
+		}
+		for _, sub := range ranked {
+			genre.genre.SubGenres = append(genre.genre.SubGenres, sub.genre)
+		}
+		genres = append(genres, genre)
+	}
+
+	sort.Slice(genres, func(a, b int) bool {
+		if genres[a].score != genres[b].score {
+			return genres[a].score > genres[b].score
+		}
+		return genres[a].genre.MainGenre < genres[b].genre.MainGenre
+	})
+	if len(genres) > limit {
+		genres = genres[:limit]
+	}
+
+	result := make([]models.OnboardingGenre, 0, len(genres))
+	for _, genre := range genres {
+		result = append(result, genre.genre)
+	}
+	return result
+}
+
+// OnboardingPreferences turns a questionnaire into preferences to seed
+// user_preferences with. Every pick must exist in the catalog.
+func OnboardingPreferences(catalog *Catalog, selection models.OnboardingSelection) (models.UserPreferencesMap, error) {
+	snapshot := catalog.current()
+	prefs := models.UserPreferencesMap{}
+
+	set := func(attribute, value string, weight float64) {
+		if prefs[attribute] == nil {
+			prefs[attribute] = map[string]float64{}
+		}
+		if weight > prefs[attribute][value] {
+			prefs[attribute][value] = weight
+		}
+	}
+
+	picks := []struct {
+		attribute string
+		values    []string
+		weight    float64
+	}{
+		{"Main Genre", selection.MainGenres, onboardingGenreWeight},
+		{"Sub Genre", selection.SubGenres, onboardingGenreWeight},
+		{"Author", selection.Authors, onboardingAuthorWeight},
+	}
+
+	for _, pick := range picks {
+		for _, value := range pick.values {
+			if pick.attribute == "Author" {
+				value = catalog.CanonicalAuthor(value)
+			}
+			if _, ok := snapshot.featureIDs[pick.attribute][value]; !ok {
+				return nil, fmt.Errorf("unknown %s %q", pick.attribute, value)
+			}
+			set(pick.attribute, value, pick.weight)
+		}
+	}
+
+	for _, id := range selection.BookIDs {
+		i, ok := snapshot.byID[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown book %d", id)
+		}
+		for _, attribute := range Attributes {
+			if value := AttributeValue(snapshot.books[i], attribute); value != "" {
+				set(attribute, value, onboardingBookWeight)
+			}
+		}
+	}
+
+	if len(prefs) == 0 {
+		return nil, ErrEmptySelection
+	}
+
+	return prefs, nil
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+
+	"book-recommendation-service/pkg/models"
+)
+
+const (
+	// maxPopularPerSubGenre keeps the popular set from being one sub-genre
+	maxPopularPerSubGenre = 2
+	// qualityPrior is how many ratings a book needs before its rating counts fully
+	qualityPrior = 50.0
+)
+
+// PopularRecommender recommends the books whose genres, types and authors are
+// liked the most across all users, per global_preferences, favoring well
+// rated books. It needs nothing from the user, so it serves new users.
+type PopularRecommender struct {
+	catalog *Catalog
+	prefs   PreferenceSource
+}
+
+func NewPopularRecommender(catalog *Catalog, prefs PreferenceSource) *PopularRecommender {
+	return &PopularRecommender{catalog: catalog, prefs: prefs}
+}
+
+func (p *PopularRecommender) GetRecommendations(ctx context.Context, req models.RecommendationRequest) (*models.RecommendationResponse, error) {
+	snapshot := p.catalog.current()
+	globals := p.globalPreferences()
+
+	exclude := map[string]bool{}
+	for _, title := range req.UserBookTitles {
+		exclude[normalizeTitle(title)] = true
+	}
+
+	perSubGenre := map[string]int{}
+	response := &models.RecommendationResponse{RecommendedTitles: []string{}}
+
+	for _, scored := range popularityScores(snapshot, globals) {
+		if len(response.RecommendedTitles) == DefaultRecommendationCount {
+			break
+		}
+		book := snapshot.books[scored.index]
+		if exclude[normalizeTitle(book.Title)] || perSubGenre[book.SubGenre] == maxPopularPerSubGenre {
+			continue
+		}
+		exclude[normalizeTitle(book.Title)] = true
+		perSubGenre[book.SubGenre]++
+
+		response.RecommendedTitles = append(response.RecommendedTitles, book.Title)
+		response.Recommendations = append(response.Recommendations, models.Recommendation{
+			BookID:  book.ID,
+			Title:   book.Title,
+			Score:   scored.score,
+			Reasons: []models.Reason{popularReason(book, globals)},
+		})
+	}
+
+	return response, nil
+}
+
+// popularReason credits the book's genre when it is liked by users at all,
+// and otherwise its rating
+func popularReason(book models.Book, globals models.UserPreferencesMap) models.Reason {
+	genre := popularGenre(book, globals)
+	if globals["Sub Genre"][genre] > 0 || globals["Main Genre"][genre] > 0 {
+		return popularIn(genre)
+	}
+	return highlyRated()
+}
+
+func (p *PopularRecommender) globalPreferences() models.UserPreferencesMap {
+	if p.prefs == nil {
+		return models.UserPreferencesMap{}
+	}
+
+	globals, err := p.prefs.GetGlobalPreferences()
+	if err != nil {
+		log.Printf("Ranking popular books by rating only: %v", err)
+		return models.UserPreferencesMap{}
+	}
+	return p.catalog.canonicalPreferences(globals)
+}
+
+// popularityScores ranks every book by the global weights of its attributes,
+// scaled to [0, 1], and by its quality, half each
+func popularityScores(snapshot *catalogSnapshot, globals models.UserPreferencesMap) []scoredBook {
+	attributes := attributeScores(snapshot, globals)
+
+	var max float64
+	for _, score := range attributes {
+		if score > max {
+			max = score
+		}
+	}
+
+	scores := make([]scoredBook, 0, len(snapshot.books))
+	for i, book := range snapshot.books {
+		score := quality(book)
+		if max > 0 {
+			score = (score + attributes[i]/max) / 2
+		}
+		scores = append(scores, scoredBook{index: i, score: score})
+	}
+
+	sort.SliceStable(scores, func(a, b int) bool {
+		return scores[a].score > scores[b].score
+	})
+
+	return scores
+}
+
+// quality is the book's rating out of 5, scaled to [0, 1] and discounted
+// while it has few ratings
+func quality(book models.Book) float64 {
+	count := float64(book.RatingsCount)
+	return book.Rating / 5 * (1 - math.Exp(-count/qualityPrior))
+}
+
+// popularFallback answers with the popular set whenever the recommender it
+// wraps has nothing to recommend, as for users without any interactions
+type popularFallback struct {
+	next    Recommender
+	popular *PopularRecommender
+}
+
+// WithPopularFallback wraps a recommender so that empty answers are replaced
+// by the popular set
+func WithPopularFallback(next Recommender, popular *PopularRecommender) Recommender {
+	return &popularFallback{next: next, popular: popular}
+}
+
+func (f *popularFallback) GetRecommendations(ctx context.Context, req models.RecommendationRequest) (*models.RecommendationResponse, error) {
+	response, err := f.next.GetRecommendations(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.RecommendedTitles) > 0 || len(response.Recommendations) > 0 {
+		return response, nil
+	}
+
+	return f.popular.GetRecommendations(ctx, req)
+}
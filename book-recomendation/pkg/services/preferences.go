@@ -0,0 +1,140 @@
+package services
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"book-recommendation-service/pkg/models"
+)
+
+// InteractionStore reads interaction events and stores the preferences
+// learned from them, see db.Repository
+type InteractionStore interface {
+	GetUsersWithInteractionsSince(since time.Time) ([]int, error)
+	GetUserBookInteractions(userID int) ([]models.BookInteraction, error)
+	SetLearnedPreferences(userID int, prefs []models.UserPreference) error
+}
+
+// aggregateOverlap is how far back each run looks past the previous one, so
+// events stamped by a database clock slightly behind ours are not missed
+const aggregateOverlap = time.Minute
+
+// PreferenceAggregator learns user_preferences from interaction events. Each
+// run relearns the preferences of the users with new events from all of
+// their events, older events counting less.
+type PreferenceAggregator struct {
+	store    InteractionStore
+	catalog  *Catalog
+	halfLife time.Duration
+
+	mu    sync.Mutex
+	since time.Time
+}
+
+func NewPreferenceAggregator(store InteractionStore, catalog *Catalog, halfLife time.Duration) *PreferenceAggregator {
+	return &PreferenceAggregator{store: store, catalog: catalog, halfLife: halfLife}
+}
+
+// Run relearns the preferences of every user with events since the last run.
+// The first run covers every user with events.
+func (a *PreferenceAggregator) Run() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	start := time.Now()
+
+	users, err := a.store.GetUsersWithInteractionsSince(a.since)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range users {
+		interactions, err := a.store.GetUserBookInteractions(userID)
+		if err != nil {
+			log.Printf("Error loading interactions of user %d: %v", userID, err)
+			continue
+		}
+
+		prefs := LearnPreferences(interactions, a.catalog, start, a.halfLife)
+		if err := a.store.SetLearnedPreferences(userID, prefs); err != nil {
+			log.Printf("Error storing preferences of user %d: %v", userID, err)
+		}
+	}
+
+	a.since = start.Add(-aggregateOverlap)
+	if len(users) > 0 {
+		log.Printf("Learned preferences of %d users in %s", len(users), time.Since(start))
+	}
+
+	return nil
+}
+
+// Start runs the aggregator on every tick of interval
+func (a *PreferenceAggregator) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := a.Run(); err != nil {
+				log.Printf("Error aggregating preferences: %v", err)
+			}
+		}
+	}()
+}
+
+// LearnPreferences weighs every attribute value of the books the user
+// interacted with by the InteractionWeight of each interaction, halved for
+// every halfLife of its age. The summed score s becomes a weight of
+// 1 - e^-s: one fresh favorite gives 0.63, several approach 1.
+func LearnPreferences(interactions []models.BookInteraction, catalog *Catalog, now time.Time, halfLife time.Duration) []models.UserPreference {
+	scores := map[string]map[string]float64{}
+	counts := map[string]map[string]int{}
+	for _, attribute := range Attributes {
+		scores[attribute] = map[string]float64{}
+		counts[attribute] = map[string]int{}
+	}
+
+	for _, interaction := range interactions {
+		weight := InteractionWeight(interaction)
+		if weight <= 0 {
+			continue
+		}
+
+		book, ok := catalog.FindByID(interaction.BookID)
+		if !ok {
+			continue
+		}
+
+		if created, err := time.Parse(time.RFC3339Nano, interaction.CreatedAt); err == nil && halfLife > 0 {
+			if age := now.Sub(created); age > 0 {
+				weight *= math.Exp2(-float64(age) / float64(halfLife))
+			}
+		}
+
+		for _, attribute := range Attributes {
+			value := AttributeValue(book, attribute)
+			if value == "" {
+				continue
+			}
+			scores[attribute][value] += weight
+			counts[attribute][value]++
+		}
+	}
+
+	var prefs []models.UserPreference
+	for _, attribute := range Attributes {
+		for value, score := range scores[attribute] {
+			prefs = append(prefs, models.UserPreference{
+				Category: attribute,
+				Value:    value,
+				Weight:   1 - math.Exp(-score),
+				Count:    counts[attribute][value],
+			})
+		}
+	}
+
+	return prefs
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"fmt"
+
+	"book-recommendation-service/pkg/models"
+)
+
+// Reason codes of a recommendation
+const (
+	ReasonBecauseYouLiked   = "because_you_liked"
+	ReasonReadersAlsoLiked  = "readers_also_liked"
+	ReasonPopularIn         = "popular_in"
+	ReasonMatchesPreference = "matches_preference"
+	ReasonHighlyRated       = "highly_rated"
+)
+
+func becauseYouLiked(title string) models.Reason {
+	return models.Reason{Code: ReasonBecauseYouLiked, Message: fmt.Sprintf("because you liked %s", title)}
+}
+
+func readersAlsoLiked(title string) models.Reason {
+	return models.Reason{Code: ReasonReadersAlsoLiked, Message: fmt.Sprintf("readers who liked %s also liked this", title)}
+}
+
+func popularIn(genre string) models.Reason {
+	return models.Reason{Code: ReasonPopularIn, Message: fmt.Sprintf("popular in %s", genre)}
+}
+
+func matchesPreference(value string) models.Reason {
+	return models.Reason{Code: ReasonMatchesPreference, Message: fmt.Sprintf("matches your interest in %s", value)}
+}
+
+func highlyRated() models.Reason {
+	return models.Reason{Code: ReasonHighlyRated, Message: "highly rated by readers"}
+}
+
+// strongestPreference returns the book's attribute value the preferences
+// weigh the most, and false when they weigh none of them
+func strongestPreference(book models.Book, prefs models.UserPreferencesMap) (string, float64, bool) {
+	var best string
+	var bestWeight float64
+	for _, attribute := range Attributes {
+		value := AttributeValue(book, attribute)
+		if w, ok := prefs[attribute][value]; ok && value != "" && w > bestWeight {
+			best, bestWeight = value, w
+		}
+	}
+	return best, bestWeight, best != ""
+}
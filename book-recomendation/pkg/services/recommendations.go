@@ -2,34 +2,152 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"math/rand"
+	"net"
 	"net/http"
+	"time"
 
 	"book-recommendation-service/pkg/models"
 )
 
+// ModelServiceOptions configure the client of the Python model service
+type ModelServiceOptions struct {
+	URL string
+	// Timeout is the deadline of each attempt
+	Timeout time.Duration
+	// Retries is how many times a call that could not connect is retried
+	Retries int
+	// Backoff is the wait before the first retry; it doubles with every
+	// retry and is jittered
+	Backoff time.Duration
+	// BreakerFailures is how many failures in a row open the circuit
+	// breaker, which then stays open for BreakerCooldown
+	BreakerFailures int
+	BreakerCooldown time.Duration
+	// CacheTTL is how long responses are cached per user and books
+	CacheTTL time.Duration
+}
+
+// RecommendationService is the client of the Python model service in
+// Amazon_Books_Scraping. Every attempt has a deadline and calls that could not
+// connect are retried with backoff. While the service keeps failing, the
+// circuit breaker stops calling it and the fallback answers instead.
 type RecommendationService struct {
-	client           *http.Client
-	friendServiceURL string
+	client   *http.Client
+	opts     ModelServiceOptions
+	breaker  *circuitBreaker
+	cache    *responseCache
+	fallback Recommender
+}
+
+// errUnavailable marks failures of the model service itself: transport
+// errors, timeouts, 5xx or 429 responses and answers that cannot be read.
+// They count against the circuit breaker and the fallback answers them. Only
+// those with retry set are worth another attempt, see post.
+type errUnavailable struct {
+	err   error
+	retry bool
 }
 
-func NewRecommendationService(friendServiceURL string) *RecommendationService {
+func (e errUnavailable) Error() string { return e.err.Error() }
+func (e errUnavailable) Unwrap() error { return e.err }
+
+func NewRecommendationService(opts ModelServiceOptions, fallback Recommender) *RecommendationService {
 	return &RecommendationService{
-		client:           &http.Client{},
-		friendServiceURL: friendServiceURL,
+		client:   &http.Client{},
+		opts:     opts,
+		breaker:  newCircuitBreaker(opts.BreakerFailures, opts.BreakerCooldown),
+		cache:    newResponseCache(opts.CacheTTL),
+		fallback: fallback,
 	}
 }
 
-func (s *RecommendationService) GetRecommendations(req models.RecommendationRequest) (*models.RecommendationResponse, error) {
+// GetRecommendations answers from the cache, then the model service, and
+// from the fallback when the breaker is open or the service failed.
+func (s *RecommendationService) GetRecommendations(ctx context.Context, req models.RecommendationRequest) (*models.RecommendationResponse, error) {
+	if response, ok := s.cache.get(req); ok {
+		return response, nil
+	}
+
+	response, err := s.call(ctx, req)
+	if err == nil {
+		s.cache.put(req, response)
+		return response, nil
+	}
 
+	var unavailable errUnavailable
+	if s.fallback == nil || ctx.Err() != nil || !(errors.Is(err, ErrCircuitOpen) || errors.As(err, &unavailable)) {
+		return nil, err
+	}
+
+	log.Printf("Serving fallback recommendations: %v", err)
+	return s.fallback.GetRecommendations(ctx, req)
+}
+
+// call posts the request to the model service through the circuit breaker,
+// retrying attempts that could not connect
+func (s *RecommendationService) call(ctx context.Context, req models.RecommendationRequest) (*models.RecommendationResponse, error) {
 	jsonData, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("error marshalling request: %v", err)
 	}
 
-	url := s.friendServiceURL + "/recommendations"
-	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	for attempt := 0; ; attempt++ {
+		if !s.breaker.allow() {
+			return nil, ErrCircuitOpen
+		}
+
+		response, err := s.post(ctx, jsonData)
+		if err != nil && ctx.Err() != nil {
+			// a cancelled caller says nothing about the service's health
+			s.breaker.release()
+			return nil, err
+		}
+
+		var unavailable errUnavailable
+		if err == nil || !errors.As(err, &unavailable) {
+			s.breaker.success()
+			return response, err
+		}
+
+		s.breaker.failure()
+		if !unavailable.retry || attempt == s.opts.Retries {
+			return nil, err
+		}
+
+		select {
+		case <-time.After(s.backoff(attempt)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// backoff waits a random time up to Backoff doubled for every attempt, so
+// retries of concurrent requests spread out
+func (s *RecommendationService) backoff(attempt int) time.Duration {
+	max := s.opts.Backoff << attempt
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// post makes one attempt within the per-attempt deadline
+func (s *RecommendationService) post(ctx context.Context, jsonData []byte) (*models.RecommendationResponse, error) {
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+
+	url := s.opts.URL + "/recommendations"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %v", err)
 	}
@@ -37,17 +155,26 @@ func (s *RecommendationService) GetRecommendations(req models.RecommendationRequ
 
 	resp, err := s.client.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %v", err)
+		// The model service updates the user's preferences as it
+		// recommends, so a request that may have reached it is not sent
+		// again. Only a failed dial proves it did not.
+		var opErr *net.OpError
+		dialFailed := errors.As(err, &opErr) && opErr.Op == "dial"
+		return nil, errUnavailable{fmt.Errorf("error sending request: %v", err), dialFailed}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error from Python service: %s", resp.Status)
+		err := fmt.Errorf("error from Python service: %s", resp.Status)
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return nil, errUnavailable{err: err}
+		}
+		return nil, err
 	}
 
 	var response models.RecommendationResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+		return nil, errUnavailable{err: fmt.Errorf("error decoding response: %v", err)}
 	}
 
 	return &response, nil
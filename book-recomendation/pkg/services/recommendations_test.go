@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"book-recommendation-service/pkg/models"
+)
+
+// staticRecommender answers every request with the same titles
+type staticRecommender []string
+
+func (r staticRecommender) GetRecommendations(ctx context.Context, req models.RecommendationRequest) (*models.RecommendationResponse, error) {
+	return &models.RecommendationResponse{RecommendedTitles: r}, nil
+}
+
+func TestRecommendationServiceRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		calls    int32
+		fallback bool
+		failures int
+	}{
+		{"success", http.StatusOK, 1, false, 0},
+		// the service may have updated preferences before failing
+		{"server error is not retried", http.StatusInternalServerError, 1, true, 1},
+		{"overloaded is not retried", http.StatusTooManyRequests, 1, true, 1},
+		{"bad request", http.StatusBadRequest, 1, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tt.status)
+				if tt.status == http.StatusOK {
+					w.Write([]byte(`{"recommended_titles":["Dune"]}`))
+				}
+			}))
+			defer server.Close()
+
+			s := NewRecommendationService(ModelServiceOptions{URL: server.URL, Retries: 2, BreakerFailures: 10}, staticRecommender{"Fallback"})
+			response, err := s.GetRecommendations(context.Background(), models.RecommendationRequest{UserID: 1})
+
+			if calls != tt.calls {
+				t.Errorf("the service was called %d times, want %d", calls, tt.calls)
+			}
+			if s.breaker.failures != tt.failures {
+				t.Errorf("the breaker counted %d failures, want %d", s.breaker.failures, tt.failures)
+			}
+
+			switch {
+			case tt.status == http.StatusOK:
+				if err != nil || response.RecommendedTitles[0] != "Dune" {
+					t.Errorf("got %v, %v, want the service's answer", response, err)
+				}
+			case tt.fallback:
+				if err != nil || response.RecommendedTitles[0] != "Fallback" {
+					t.Errorf("got %v, %v, want the fallback's answer", response, err)
+				}
+			default:
+				if err == nil {
+					t.Errorf("got %v, want an error", response)
+				}
+			}
+		})
+	}
+}
+
+func TestRecommendationServiceRetriesDialErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	s := NewRecommendationService(ModelServiceOptions{URL: url, Retries: 2, BreakerFailures: 10}, nil)
+	_, err := s.GetRecommendations(context.Background(), models.RecommendationRequest{UserID: 1})
+
+	var unavailable errUnavailable
+	if !errors.As(err, &unavailable) || !unavailable.retry {
+		t.Fatalf("got %v, want a retryable error", err)
+	}
+	if s.breaker.failures != 3 {
+		t.Errorf("made %d attempts, want the first and 2 retries", s.breaker.failures)
+	}
+}
+
+func TestRecommendationServiceCache(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"recommended_titles":["Dune"]}`))
+	}))
+	defer server.Close()
+
+	s := NewRecommendationService(ModelServiceOptions{URL: server.URL, CacheTTL: time.Minute}, nil)
+	for i := 0; i < 3; i++ {
+		if _, err := s.GetRecommendations(context.Background(), models.RecommendationRequest{UserID: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("the service was called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"book-recommendation-service/pkg/models"
+)
+
+// DefaultRecommendationCount is how many books a recommendation returns, as in model.py
+const DefaultRecommendationCount = 10
+
+// Recommender produces book recommendations for a user. The native
+// recommenders and the Python model client are interchangeable.
+type Recommender interface {
+	GetRecommendations(ctx context.Context, req models.RecommendationRequest) (*models.RecommendationResponse, error)
+}
+
+// PreferenceSource provides the learned preferences of a user and those of
+// all users, see db.Repository
+type PreferenceSource interface {
+	GetUserPreferences(userID int) ([]models.UserPreference, error)
+	GetGlobalPreferences() (models.UserPreferencesMap, error)
+}
+
+// NewRecommender returns the recommender selected by name: "hybrid" to blend
+// every signal with weights, "native" for the in-process content-based
+// recommender, "collaborative" for the item-item collaborative filter or
+// "python" for the model service, which falls back to popular books while
+// it is down.
+func NewRecommender(name string, catalog *Catalog, prefs PreferenceSource, cf *CollaborativeRecommender, weights HybridWeights, model ModelServiceOptions) (Recommender, error) {
+	switch name {
+	case "hybrid":
+		return NewHybridRecommender(catalog, prefs, cf, weights), nil
+	case "native":
+		return NewContentRecommender(catalog, prefs), nil
+	case "collaborative":
+		return cf, nil
+	case "python":
+		return NewRecommendationService(model, NewPopularRecommender(catalog, prefs)), nil
+	default:
+		return nil, fmt.Errorf("unknown recommender %q", name)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"book-service/internal/domain"
+	"book-service/internal/filters"
+	"book-service/internal/validator"
+	"net/http"
+)
+
+func (app *application) showFeedHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	var input struct {
+		filters.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = "-created_at"
+	input.Filters.SortSafelist = []string{"-created_at"}
+
+	if filters.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	events, metadata, err := app.models.Activity.GetFeed(user.ID, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"feed": events, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// recordActivity stores an activity event for the feed. Comments and ratings
+// name their owner in the request, so the event is only recorded when the
+// signed in user is that owner. The write that caused it has already
+// succeeded, so failures are logged rather than returned.
+func (app *application) recordActivity(r *http.Request, event *domain.ActivityEvent) {
+	if user := app.contextGetUser(r); user.IsAnonymous() || user.ID != event.UserID {
+		return
+	}
+
+	err := app.models.Activity.Insert(event)
+	if err != nil {
+		app.logError(r, err)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"book-service/internal/data"
+	"book-service/internal/filters"
+	"book-service/internal/recommend"
+	"book-service/internal/validator"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// bookDetailParts are the sections GET /Books/:id/details can include besides the book itself
+var bookDetailParts = []string{"editions", "rating_distribution", "recent_comments", "user_rating", "favorite", "sub_genre", "similar_books"}
+
+const bookDetailConcurrency = 4
+
+func (app *application) showBookDetailsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	qs := r.URL.Query()
+	include := app.readCSV(qs, "include", app.readCSV(qs, "fields", bookDetailParts))
+
+	v := validator.New()
+	for _, part := range include {
+		v.Check(validator.In(part, bookDetailParts...), "include", "invalid include value "+part)
+	}
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	book, err := app.models.Book.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	fetchers := map[string]func() (interface{}, error){
+		"editions": func() (interface{}, error) {
+			editions, err := app.models.Edition.GetForBooks([]int64{book.ID})
+			return editions[book.ID], err
+		},
+		"rating_distribution": func() (interface{}, error) {
+			return app.models.Rating.GetDistribution(book.ID)
+		},
+		"recent_comments": func() (interface{}, error) {
+			f := filters.Filters{Page: 1, PageSize: 5, Sort: "-created_at", SortSafelist: []string{"-created_at"}}
+			comments, _, err := app.models.Comment.GetAllForBook(book.ID, f)
+			return comments, err
+		},
+		"user_rating": func() (interface{}, error) {
+			if user.IsAnonymous() {
+				return nil, nil
+			}
+			return app.models.Rating.GetUserRatingForBook(user.ID, book.ID)
+		},
+		"favorite": func() (interface{}, error) {
+			if user.IsAnonymous() {
+				return false, nil
+			}
+			return app.models.FavoriteBook.IsFavorite(user.ID, book.Title)
+		},
+		"sub_genre": func() (interface{}, error) {
+			return app.models.SubGenre.GetByTitle(book.SubGenre, book.MainGenre)
+		},
+		"similar_books": func() (interface{}, error) {
+			return app.models.Book.GetSimilar(book, 6)
+		},
+	}
+
+	details := envelope{"book": book}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		sem      = make(chan struct{}, bookDetailConcurrency)
+	)
+
+	parts := []string{}
+	for _, part := range include {
+		if _, seen := details[part]; !seen {
+			details[part] = nil
+			parts = append(parts, part)
+		}
+	}
+
+	for _, part := range parts {
+		wg.Add(1)
+		go func(part string, fetch func() (interface{}, error)) {
+			defer wg.Done()
+			defer func() {
+				if err := recover(); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("loading %s: %s", part, err)
+					}
+					mu.Unlock()
+				}
+			}()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			value, err := fetch()
+			if errors.Is(err, data.ErrRecordNotFound) {
+				value, err = nil, nil
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			details[part] = value
+		}(part, fetchers[part])
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		app.serverErrorResponse(w, r, firstErr)
+		return
+	}
+
+	app.trackInteraction(r, recommend.Event{
+		UserID: user.ID,
+		BookID: book.ID,
+		Type:   recommend.EventView,
+	})
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"details": details}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
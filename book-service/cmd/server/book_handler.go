@@ -4,11 +4,14 @@ import (
 	"book-service/internal/data"
 	"book-service/internal/domain"
 	"book-service/internal/filters"
+	"book-service/internal/recommend"
 	"book-service/internal/validator"
 
 	"errors"
 	"fmt"
 	"net/http"
+
+	"github.com/julienschmidt/httprouter"
 )
 
 func (app *application) createBookHandler(w http.ResponseWriter, r *http.Request) {
@@ -22,6 +25,9 @@ func (app *application) createBookHandler(w http.ResponseWriter, r *http.Request
 		Rating      float64 `json:"rating"`
 		PeopleRated int64   `json:"people_rated"`
 		URL         string  `json:"url"`
+		ISBN10      string  `json:"isbn_10"`
+		ISBN13      string  `json:"isbn_13"`
+		ASIN        string  `json:"asin"`
 	}
 
 	err := app.readJSON(w, r, &input)
@@ -42,6 +48,9 @@ func (app *application) createBookHandler(w http.ResponseWriter, r *http.Request
 		Rating:      input.Rating,
 		PeopleRated: input.PeopleRated,
 		URL:         input.URL,
+		ISBN10:      input.ISBN10,
+		ISBN13:      input.ISBN13,
+		ASIN:        input.ASIN,
 	}
 
 	if data.ValidateBook(v, book); !v.Valid() {
@@ -53,6 +62,12 @@ func (app *application) createBookHandler(w http.ResponseWriter, r *http.Request
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+	app.checkPriceAlerts(book.ID)
+	app.publish(&domain.NotificationEvent{
+		Type:    domain.NotificationNewBook,
+		ActorID: app.contextGetUser(r).ID,
+		BookID:  book.ID,
+	})
 
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/books/%d", book.ID))
@@ -79,6 +94,44 @@ func (app *application) showBookHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	err = app.models.Edition.AttachEditions(book)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.trackInteraction(r, recommend.Event{
+		UserID: app.contextGetUser(r).ID,
+		BookID: book.ID,
+		Type:   recommend.EventView,
+	})
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"book": book}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showBookByISBNHandler(w http.ResponseWriter, r *http.Request) {
+	code := httprouter.ParamsFromContext(r.Context()).ByName("isbn")
+
+	book, err := app.models.Book.GetByIdentifier(code)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.Edition.AttachEditions(book)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	err = app.writeJSON(w, http.StatusOK, envelope{"book": book}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -112,6 +165,9 @@ func (app *application) updateBookHandler(w http.ResponseWriter, r *http.Request
 		Rating      *float64 `json:"rating"`
 		PeopleRated *int64   `json:"people_rated"`
 		URL         *string  `json:"url"`
+		ISBN10      *string  `json:"isbn_10"`
+		ISBN13      *string  `json:"isbn_13"`
+		ASIN        *string  `json:"asin"`
 	}
 
 	err = app.readJSON(w, r, &input)
@@ -147,6 +203,8 @@ func (app *application) updateBookHandler(w http.ResponseWriter, r *http.Request
 	if input.URL != nil {
 		book.URL = *input.URL
 	}
+	book.ISBN10, book.ISBN13, book.ASIN = app.mergeIdentifiers(
+		book.ISBN10, book.ISBN13, book.ASIN, input.ISBN10, input.ISBN13, input.ASIN, input.URL != nil)
 
 	v := validator.New()
 
@@ -165,6 +223,7 @@ func (app *application) updateBookHandler(w http.ResponseWriter, r *http.Request
 		}
 		return
 	}
+	app.checkPriceAlerts(book.ID)
 
 	err = app.writeJSON(w, http.StatusOK, envelope{"book": book}, nil)
 	if err != nil {
@@ -179,6 +238,18 @@ func (app *application) deleteBookHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// the cover files outlive the row, so look them up before deleting it
+	book, err := app.models.Book.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
 	err = app.models.Book.Delete(id)
 	if err != nil {
 		switch {
@@ -189,6 +260,7 @@ func (app *application) deleteBookHandler(w http.ResponseWriter, r *http.Request
 		}
 		return
 	}
+	app.deleteCoverFiles(book.Cover)
 
 	err = app.writeJSON(w, http.StatusOK, envelope{"message": "book successfully deleted"}, nil)
 	if err != nil {
@@ -216,7 +288,7 @@ func (app *application) listBooksHandler(w http.ResponseWriter, r *http.Request)
 
 	input.Filters.Sort = app.readString(qs, "sort", "id")
 
-	input.Filters.SortSafelist = []string{"id", "title", "author", "main_genre", "sub_genre", "type", "price", "rating", "people_rated", "-id", "-title", "-author", "-main_genre", "-sub_genre", "-type", "-price", "-rating", "-people_rated"}
+	input.Filters.SortSafelist = []string{"id", "title", "author", "main_genre", "sub_genre", "type", "price", "rating", "people_rated", "-id", "-title", "-author", "-main_genre", "-sub_genre", "-type", "-price", "-rating", "-people_rated", "weighted_rating", "-weighted_rating"}
 
 	if filters.ValidateFilters(v, input.Filters); !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
@@ -228,6 +300,13 @@ func (app *application) listBooksHandler(w http.ResponseWriter, r *http.Request)
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+
+	err = app.models.Edition.AttachEditions(books...)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	err = app.writeJSON(w, http.StatusOK, envelope{"books": books, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
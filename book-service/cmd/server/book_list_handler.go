@@ -0,0 +1,399 @@
+package main
+
+import (
+	"book-service/internal/data"
+	"book-service/internal/domain"
+	"book-service/internal/filters"
+	"book-service/internal/validator"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+func (app *application) createBookListHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	var input struct {
+		Title       string  `json:"title"`
+		Description string  `json:"description"`
+		IsPublic    *bool   `json:"is_public"`
+		BookIDs     []int64 `json:"book_ids"`
+		Editorial   bool    `json:"editorial"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	list := &domain.BookList{
+		UserID:      &user.ID,
+		Title:       input.Title,
+		Description: input.Description,
+		IsPublic:    true,
+		BookIDs:     input.BookIDs,
+	}
+	if input.IsPublic != nil {
+		list.IsPublic = *input.IsPublic
+	}
+	if list.BookIDs == nil {
+		list.BookIDs = []int64{}
+	}
+
+	if input.Editorial {
+		isEditor, err := app.isListEditor(user)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		if !isEditor {
+			app.notPermittedResponse(w, r)
+			return
+		}
+		list.UserID = nil
+	}
+
+	v := validator.New()
+	if data.ValidateBookList(v, list); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.BookList.Insert(list)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrUnknownBook):
+			v.AddError("book_ids", err.Error())
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/lists/%d", list.ID))
+	err = app.writeJSON(w, http.StatusCreated, envelope{"list": list}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showBookListHandler(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.readVisibleBookList(w, r)
+	if !ok {
+		return
+	}
+
+	books, err := app.models.BookList.GetBooks(list.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	list.Books = books
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"list": list}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateBookListHandler(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.readEditableBookList(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Title       *string `json:"title"`
+		Description *string `json:"description"`
+		IsPublic    *bool   `json:"is_public"`
+		BookIDs     []int64 `json:"book_ids"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Title != nil {
+		list.Title = *input.Title
+	}
+	if input.Description != nil {
+		list.Description = *input.Description
+	}
+	if input.IsPublic != nil {
+		list.IsPublic = *input.IsPublic
+	}
+	if input.BookIDs != nil {
+		list.BookIDs = input.BookIDs
+	}
+
+	app.saveBookList(w, r, list)
+}
+
+func (app *application) reorderBookListHandler(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.readEditableBookList(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		BookIDs []int64 `json:"book_ids"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	if v.Check(data.SameBooks(list, input.BookIDs), "book_ids", "must contain exactly the books already in the list"); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	list.BookIDs = input.BookIDs
+
+	app.saveBookList(w, r, list)
+}
+
+func (app *application) featureBookListHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	list, err := app.models.BookList.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		Featured bool   `json:"featured"`
+		Genre    string `json:"genre"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	list.IsFeatured = input.Featured
+	list.FeaturedGenre = ""
+	if input.Featured {
+		list.FeaturedGenre = input.Genre
+	}
+
+	app.saveBookList(w, r, list)
+}
+
+func (app *application) deleteBookListHandler(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.readEditableBookList(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.BookList.Delete(list.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "list successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listBookListsHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Search string
+		Mine   bool
+		filters.Filters
+	}
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Search = app.readString(qs, "search", "")
+	input.Mine = app.readString(qs, "mine", "false") == "true"
+
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+	input.Filters.Sort = app.readString(qs, "sort", "-updated_at")
+	input.Filters.SortSafelist = []string{"id", "title", "updated_at", "-id", "-title", "-updated_at"}
+
+	if filters.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	var ownerID int64
+	if input.Mine {
+		user := app.contextGetUser(r)
+		if user.IsAnonymous() {
+			app.authenticationRequiredResponse(w, r)
+			return
+		}
+		ownerID = user.ID
+	}
+
+	lists, metadata, err := app.models.BookList.GetAll(input.Search, ownerID, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"lists": lists, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listGenreFeaturedListsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	genre, err := app.models.Genre.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	lists, err := app.models.BookList.GetFeaturedForGenre(genre.Title)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"lists": lists}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) saveBookList(w http.ResponseWriter, r *http.Request, list *domain.BookList) {
+	v := validator.New()
+	if data.ValidateBookList(v, list); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err := app.models.BookList.Update(list)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrUnknownBook):
+			v.AddError("book_ids", err.Error())
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"list": list}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readVisibleBookList loads the list named in the URL if the current user may
+// see it. Private lists are reported as missing to everyone but their editors.
+func (app *application) readVisibleBookList(w http.ResponseWriter, r *http.Request) (*domain.BookList, bool) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	list, err := app.models.BookList.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	if !list.IsPublic {
+		canEdit, err := app.canEditBookList(app.contextGetUser(r), list)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return nil, false
+		}
+		if !canEdit {
+			app.notFoundResponse(w, r)
+			return nil, false
+		}
+	}
+
+	return list, true
+}
+
+func (app *application) readEditableBookList(w http.ResponseWriter, r *http.Request) (*domain.BookList, bool) {
+	list, ok := app.readVisibleBookList(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	canEdit, err := app.canEditBookList(app.contextGetUser(r), list)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return nil, false
+	}
+	if !canEdit {
+		app.notPermittedResponse(w, r)
+		return nil, false
+	}
+
+	return list, true
+}
+
+// canEditBookList reports whether user owns the list, or the list is editorial
+// and the user is an editor.
+func (app *application) canEditBookList(user *data.User, list *domain.BookList) (bool, error) {
+	if user.IsAnonymous() {
+		return false, nil
+	}
+	if list.UserID != nil {
+		return *list.UserID == user.ID, nil
+	}
+	return app.isListEditor(user)
+}
+
+func (app *application) isListEditor(user *data.User) (bool, error) {
+	permissions, err := app.models.Permissions.GetAllForUser(user.ID)
+	if err != nil {
+		return false, err
+	}
+	return permissions.Include("lists:feature"), nil
+}
@@ -4,6 +4,7 @@ import (
 	"book-service/internal/data"
 	"book-service/internal/domain"
 	"book-service/internal/filters"
+	"book-service/internal/recommend"
 	"book-service/internal/validator"
 	"errors"
 	"fmt"
@@ -12,9 +13,10 @@ import (
 
 func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		BookID  int64  `json:"book_id"`
-		UserId  int64  `json:"user_id"`
-		Content string `json:"content"`
+		BookID   int64  `json:"book_id"`
+		UserId   int64  `json:"user_id"`
+		ParentID *int64 `json:"parent_id"`
+		Content  string `json:"content"`
 	}
 
 	err := app.readJSON(w, r, &input)
@@ -26,9 +28,10 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 	v := validator.New()
 
 	comment := &domain.Comment{
-		BookID:  input.BookID,
-		UserID:  input.UserId,
-		Content: input.Content,
+		BookID:   input.BookID,
+		UserID:   input.UserId,
+		ParentID: input.ParentID,
+		Content:  input.Content,
 	}
 
 	if data.ValidateComment(v, comment); !v.Valid() {
@@ -48,12 +51,51 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if comment.ParentID != nil {
+		parent, err := app.models.Comment.Get(*comment.ParentID)
+		if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		if parent == nil || parent.BookID != comment.BookID {
+			v.AddError("parent_id", "must be a comment on the same book")
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+	}
+
 	err = app.models.Comment.Insert(comment)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
+	// POST /Comments takes the user id from the body, so only a signed in
+	// user can be the actor of a notification
+	user := app.contextGetUser(r)
+
+	if comment.ParentID != nil && !user.IsAnonymous() {
+		app.publish(&domain.NotificationEvent{
+			Type:      domain.NotificationCommentReply,
+			ActorID:   user.ID,
+			BookID:    comment.BookID,
+			SubjectID: comment.ID,
+			TargetID:  *comment.ParentID,
+		})
+	}
+
+	app.recordActivity(r, &domain.ActivityEvent{
+		UserID:    comment.UserID,
+		Type:      domain.ActivityComment,
+		BookID:    &comment.BookID,
+		SubjectID: comment.ID,
+	})
+	app.trackInteraction(r, recommend.Event{
+		UserID: comment.UserID,
+		BookID: comment.BookID,
+		Type:   recommend.EventComment,
+	})
+
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/comments/%d", comment.ID))
 
@@ -221,6 +263,74 @@ func (app *application) listBookCommentsHandler(w http.ResponseWriter, r *http.R
 	}
 }
 
+func (app *application) likeCommentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	comment, err := app.models.Comment.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	liked, err := app.models.Comment.Like(comment.ID, user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if liked {
+		comment.Likes++
+		app.publish(&domain.NotificationEvent{
+			Type:      domain.NotificationCommentLike,
+			ActorID:   user.ID,
+			BookID:    comment.BookID,
+			SubjectID: comment.ID,
+		})
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"comment": comment}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) unlikeCommentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	err = app.models.Comment.Unlike(id, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "comment successfully unliked"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
 func (app *application) getUserIDFromRequest(r *http.Request) int64 {
 	// tipo beret userid otkuda to
 	fmt.Print(r)
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"book-service/internal/data"
+	"book-service/internal/domain"
+	"book-service/internal/imaging"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// coverSizes are the thumbnail widths generated for every uploaded cover
+var coverSizes = []struct {
+	name  string
+	width int
+}{
+	{"small", 120},
+	{"medium", 300},
+	{"large", 600},
+}
+
+func (app *application) uploadCoverHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	_, err = app.models.Book.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	maxBytes := app.config.storage.maxCoverBytes
+
+	// leave some room for the multipart framing around the file itself
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+64<<10)
+
+	file, _, err := r.FormFile("cover")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesError):
+			app.failedValidationResponse(w, r, map[string]string{"cover": fmt.Sprintf("must not be larger than %d bytes", maxBytes)})
+		case errors.Is(err, http.ErrMissingFile):
+			app.failedValidationResponse(w, r, map[string]string{"cover": "must be provided"})
+		default:
+			app.badRequestResponse(w, r, err)
+		}
+		return
+	}
+	defer file.Close()
+
+	original, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	if int64(len(original)) > maxBytes {
+		app.failedValidationResponse(w, r, map[string]string{"cover": fmt.Sprintf("must not be larger than %d bytes", maxBytes)})
+		return
+	}
+
+	// trust the file contents rather than the client supplied content type
+	contentType := http.DetectContentType(original)
+	ext, ok := imaging.ContentTypes[contentType]
+	if !ok {
+		app.failedValidationResponse(w, r, map[string]string{"cover": "must be a JPEG, PNG or GIF image"})
+		return
+	}
+
+	img, _, err := imaging.Decode(original)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrTooLarge):
+			app.failedValidationResponse(w, r, map[string]string{"cover": fmt.Sprintf("must not have more than %d pixels", imaging.MaxPixels)})
+		default:
+			app.failedValidationResponse(w, r, map[string]string{"cover": "must be a valid image"})
+		}
+		return
+	}
+
+	// every upload gets a fresh prefix so cached copies of the old cover are never served
+	prefix, err := randomHex(8)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	prefix = fmt.Sprintf("covers/%d/%s", id, prefix)
+
+	cover := &domain.Cover{
+		Thumbnails:  map[string]string{},
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		ContentType: contentType,
+		UpdatedAt:   time.Now().UTC(),
+	}
+
+	key := prefix + "/original." + ext
+	err = app.storage.Put(r.Context(), key, original, contentType)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	cover.Keys = append(cover.Keys, key)
+	cover.URL = app.storage.URL(key)
+
+	for _, size := range coverSizes {
+		thumbnail, err := imaging.EncodeJPEG(imaging.Thumbnail(img, size.width), 85)
+		if err == nil {
+			key = prefix + "/" + size.name + ".jpg"
+			err = app.storage.Put(r.Context(), key, thumbnail, "image/jpeg")
+		}
+		if err != nil {
+			app.deleteCoverFiles(cover)
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		cover.Keys = append(cover.Keys, key)
+		cover.Thumbnails[size.name] = app.storage.URL(key)
+	}
+
+	old, err := app.models.Book.SetCover(id, cover)
+	if err != nil {
+		app.deleteCoverFiles(cover)
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	app.deleteCoverFiles(old)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"cover": cover}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteCoverHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	old, err := app.models.Book.SetCover(id, nil)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	if old == nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+	app.deleteCoverFiles(old)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "cover successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteCoverFiles removes a replaced or abandoned cover from storage in the background
+func (app *application) deleteCoverFiles(cover *domain.Cover) {
+	if cover == nil || len(cover.Keys) == 0 {
+		return
+	}
+
+	app.background(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		for _, key := range cover.Keys {
+			err := app.storage.Delete(ctx, key)
+			if err != nil {
+				app.logger.PrintError(err, map[string]string{"key": key})
+			}
+		}
+	})
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"book-service/internal/data"
+	"book-service/internal/domain"
+	"book-service/internal/validator"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+func (app *application) listBookEditionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	book, err := app.models.Book.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	editions, err := app.models.Edition.GetForBooks([]int64{book.ID})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"editions": editions[book.ID]}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createEditionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Type      string `json:"type"`
+		Price     string `json:"price"`
+		URL       string `json:"url"`
+		ISBN10    string `json:"isbn_10"`
+		ISBN13    string `json:"isbn_13"`
+		ASIN      string `json:"asin"`
+		IsPrimary bool   `json:"is_primary"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	edition := &domain.Edition{
+		BookID:    id,
+		Type:      input.Type,
+		Price:     input.Price,
+		URL:       input.URL,
+		ISBN10:    input.ISBN10,
+		ISBN13:    input.ISBN13,
+		ASIN:      input.ASIN,
+		IsPrimary: input.IsPrimary,
+	}
+
+	v := validator.New()
+	if data.ValidateEdition(v, edition); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Edition.Insert(edition)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	app.checkPriceAlerts(edition.BookID)
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/editions/%d", edition.ID))
+	err = app.writeJSON(w, http.StatusCreated, envelope{"edition": edition}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateEditionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	edition, err := app.models.Edition.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		Type      *string `json:"type"`
+		Price     *string `json:"price"`
+		URL       *string `json:"url"`
+		ISBN10    *string `json:"isbn_10"`
+		ISBN13    *string `json:"isbn_13"`
+		ASIN      *string `json:"asin"`
+		IsPrimary *bool   `json:"is_primary"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Type != nil {
+		edition.Type = *input.Type
+	}
+	if input.Price != nil {
+		edition.Price = *input.Price
+	}
+	if input.URL != nil {
+		edition.URL = *input.URL
+	}
+	edition.ISBN10, edition.ISBN13, edition.ASIN = app.mergeIdentifiers(
+		edition.ISBN10, edition.ISBN13, edition.ASIN, input.ISBN10, input.ISBN13, input.ASIN, input.URL != nil)
+
+	v := validator.New()
+
+	if input.IsPrimary != nil {
+		// a book always keeps one primary edition, so it can only be handed over
+		v.Check(*input.IsPrimary || !edition.IsPrimary, "is_primary", "make another edition primary instead")
+		edition.IsPrimary = edition.IsPrimary || *input.IsPrimary
+	}
+
+	if data.ValidateEdition(v, edition); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Edition.Update(edition)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	app.checkPriceAlerts(edition.BookID)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"edition": edition}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteEditionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Edition.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrPrimaryEdition):
+			v := validator.New()
+			v.AddError("edition", err.Error())
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "edition successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// mergeIdentifiers applies a partial update to a set of identifiers. A new
+// value for one ISBN form clears the other, and a new URL clears the ASIN, so
+// the models derive them again instead of keeping values that no longer match.
+func (app *application) mergeIdentifiers(isbn10, isbn13, asin string, newISBN10, newISBN13, newASIN *string, urlChanged bool) (string, string, string) {
+	if newISBN10 != nil {
+		isbn10 = *newISBN10
+		if newISBN13 == nil {
+			isbn13 = ""
+		}
+	}
+	if newISBN13 != nil {
+		isbn13 = *newISBN13
+		if newISBN10 == nil {
+			isbn10 = ""
+		}
+	}
+	if newASIN != nil {
+		asin = *newASIN
+	} else if urlChanged {
+		asin = ""
+	}
+	return isbn10, isbn13, asin
+}
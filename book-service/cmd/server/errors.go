@@ -81,3 +81,8 @@ func (app *application) notPermittedResponse(w http.ResponseWriter, r *http.Requ
 	message := "your user account doesn't have the necessary permissions to access this resource"
 	app.errorResponse(w, r, http.StatusForbidden, message)
 }
+
+func (app *application) recommendationsUnavailableResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the recommendation service is not configured"
+	app.errorResponse(w, r, http.StatusServiceUnavailable, message)
+}
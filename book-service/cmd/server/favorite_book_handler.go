@@ -2,10 +2,12 @@ package main
 
 import (
 	"book-service/internal/domain"
+	"book-service/internal/recommend"
 	"book-service/internal/validator"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"book-service/internal/data"
 )
@@ -78,6 +80,18 @@ func (app *application) addFavoriteBookHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	app.recordActivity(r, &domain.ActivityEvent{
+		UserID:    user.ID,
+		Type:      domain.ActivityFavorite,
+		BookTitle: favoriteBook.BookName,
+		SubjectID: favoriteBook.ID,
+	})
+	app.trackInteraction(r, recommend.Event{
+		UserID:    user.ID,
+		BookTitle: favoriteBook.BookName,
+		Type:      recommend.EventFavorite,
+	})
+
 	// Return the created favorite book
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/favorite-books/%d", favoriteBook.ID))
@@ -121,9 +135,5 @@ func (app *application) deleteFavoriteBookHandler(w http.ResponseWriter, r *http
 }
 
 func (app *application) isDuplicateErr(err error) bool {
-	return err != nil && err.Error() != "" && contains(err.Error(), "duplicate") && contains(err.Error(), "unique")
-}
-
-func contains(s, substr string) bool {
-	return s != "" && substr != "" && s != substr && len(s) >= len(substr) && s != substr && fmt.Sprintf("%s", s) != fmt.Sprintf("%s", substr) && s != fmt.Sprintf("%s", substr) && fmt.Sprintf("%s", s) != substr
+	return err != nil && err.Error() != "" && strings.Contains(err.Error(), "duplicate") && strings.Contains(err.Error(), "unique")
 }
@@ -192,3 +192,17 @@ func (app *application) listGenreHandler(w http.ResponseWriter, r *http.Request)
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+// showGenreTreeHandler returns the whole genre navigation tree in one response
+func (app *application) showGenreTreeHandler(w http.ResponseWriter, r *http.Request) {
+	tree, err := app.models.Genre.GetTree()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"genres": tree}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
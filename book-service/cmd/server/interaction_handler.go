@@ -0,0 +1,65 @@
+package main
+
+import (
+	"book-service/internal/data"
+	"book-service/internal/recommend"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// purchaseClickHandler records that the user followed the link to buy the
+// book and returns the link
+func (app *application) purchaseClickHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	book, err := app.models.Book.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.trackInteraction(r, recommend.Event{
+		UserID: app.contextGetUser(r).ID,
+		BookID: book.ID,
+		Type:   recommend.EventPurchaseClick,
+	})
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"url": book.URL}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// trackInteraction reports an interaction to the recommendation service in
+// the background. Like recordActivity it only reports interactions of the
+// signed in user, as comments and ratings name their owner in the request.
+// Failures are logged since the request that caused the event has already
+// succeeded.
+func (app *application) trackInteraction(r *http.Request, event recommend.Event) {
+	if app.recommender == nil {
+		return
+	}
+	if user := app.contextGetUser(r); user.IsAnonymous() || user.ID != event.UserID {
+		return
+	}
+
+	app.background(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := app.recommender.SendEvent(ctx, event); err != nil {
+			app.logger.PrintError(err, map[string]string{"type": event.Type})
+		}
+	})
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"time"
 
 	"book-service/internal/jsonlog"
+	"book-service/internal/notify"
+	"book-service/internal/recommend"
+	"book-service/internal/storage"
 
 	"book-service/internal/data"
 
@@ -35,12 +39,42 @@ type config struct {
 		burst   int
 		enabled bool
 	}
+	rankings struct {
+		interval time.Duration
+		enabled  bool
+	}
+	recommendations struct {
+		url   string
+		token string
+	}
+	storage struct {
+		backend       string
+		dir           string
+		baseURL       string
+		maxCoverBytes int64
+		s3            struct {
+			endpoint  string
+			region    string
+			bucket    string
+			accessKey string
+			secretKey string
+			publicURL string
+			pathStyle bool
+		}
+	}
 }
 
 type application struct {
-	config config
-	logger *jsonlog.Logger
-	models data.Models
+	config      config
+	logger      *jsonlog.Logger
+	models      data.Models
+	storage     storage.Storage
+	broker      *notify.Broker
+	notifier    *notify.Publisher
+	recommender *recommend.Client
+	// stopRankings stops the rankings job, waiting for a running
+	// recompute; nil when the job is disabled
+	stopRankings func()
 }
 
 func main() {
@@ -59,9 +93,28 @@ func main() {
 	flag.Float64Var(&cfg.limiter.rps, "limiter-rps", 2, "Rate limiter maximum requests per second")
 	flag.IntVar(&cfg.limiter.burst, "limiter-burst", 4, "Rate limiter maximum burst")
 	flag.BoolVar(&cfg.limiter.enabled, "limiter-enabled", true, "Enable rate limiter")
+	flag.DurationVar(&cfg.rankings.interval, "rankings-interval", 15*time.Minute, "How often book rankings are recomputed")
+	flag.BoolVar(&cfg.rankings.enabled, "rankings-enabled", true, "Enable the background rankings job")
+	flag.StringVar(&cfg.recommendations.url, "recommendations-url", os.Getenv("RECOMMENDATIONS_URL"), "Recommendation service URL interactions are reported to, e.g. http://go_recommendation_service:8080")
+	flag.StringVar(&cfg.recommendations.token, "recommendations-token", os.Getenv("SERVICE_TOKEN"), "Secret the recommendation service requires with interactions")
+	flag.StringVar(&cfg.storage.backend, "storage", "local", "Cover image storage backend (local|s3)")
+	flag.StringVar(&cfg.storage.dir, "storage-dir", "uploads", "Directory covers are kept in by the local backend")
+	flag.StringVar(&cfg.storage.baseURL, "storage-base-url", "/api/books/uploads", "Public URL the local storage directory is served from")
+	flag.Int64Var(&cfg.storage.maxCoverBytes, "covers-max-bytes", 5<<20, "Maximum size of an uploaded cover image")
+	flag.StringVar(&cfg.storage.s3.endpoint, "s3-endpoint", os.Getenv("S3_ENDPOINT"), "S3 compatible endpoint, e.g. http://minio:9000")
+	flag.StringVar(&cfg.storage.s3.region, "s3-region", os.Getenv("S3_REGION"), "S3 region")
+	flag.StringVar(&cfg.storage.s3.bucket, "s3-bucket", os.Getenv("S3_BUCKET"), "S3 bucket covers are kept in")
+	flag.StringVar(&cfg.storage.s3.accessKey, "s3-access-key", os.Getenv("S3_ACCESS_KEY"), "S3 access key")
+	flag.StringVar(&cfg.storage.s3.secretKey, "s3-secret-key", os.Getenv("S3_SECRET_KEY"), "S3 secret key")
+	flag.StringVar(&cfg.storage.s3.publicURL, "s3-public-url", os.Getenv("S3_PUBLIC_URL"), "Public URL of the bucket, defaults to the bucket URL")
+	flag.BoolVar(&cfg.storage.s3.pathStyle, "s3-path-style", true, "Use path style bucket addressing (needed by MinIO)")
 	flag.Parse()
 
 	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	if cfg.rankings.interval <= 0 {
+		logger.PrintFatal(fmt.Errorf("rankings-interval must be positive, got %s", cfg.rankings.interval), nil)
+	}
+
 	db, err := openDB(cfg)
 	if err != nil {
 		logger.PrintFatal(err, nil)
@@ -74,10 +127,29 @@ func main() {
 	}(db)
 	logger.PrintInfo("database connection pool established", nil)
 
+	store, err := openStorage(cfg)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	models := data.NewModels(db)
+	broker := notify.NewBroker()
+
 	app := application{
-		config: cfg,
-		logger: logger,
-		models: data.NewModels(db),
+		config:   cfg,
+		logger:   logger,
+		models:   models,
+		storage:  store,
+		broker:   broker,
+		notifier: notify.NewPublisher(models.Notification, broker, logger),
+	}
+
+	if cfg.recommendations.url != "" {
+		app.recommender = recommend.NewClient(cfg.recommendations.url, cfg.recommendations.token, 5*time.Second)
+	}
+
+	if cfg.rankings.enabled {
+		app.startRankingJob()
 	}
 
 	err = app.serve()
@@ -110,6 +182,18 @@ func openDB(cfg config) (*sql.DB, error) {
 	migrationUp(db)
 	return db, nil
 }
+func openStorage(cfg config) (storage.Storage, error) {
+	switch cfg.storage.backend {
+	case "local":
+		return storage.NewLocal(cfg.storage.dir, cfg.storage.baseURL)
+	case "s3":
+		s3 := cfg.storage.s3
+		return storage.NewS3(s3.endpoint, s3.region, s3.bucket, s3.accessKey, s3.secretKey, s3.publicURL, s3.pathStyle)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.storage.backend)
+	}
+}
+
 func migrationUp(db *sql.DB) {
 	driver, err := postgres.WithInstance(db, &postgres.Config{})
 	if err != nil {
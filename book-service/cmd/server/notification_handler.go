@@ -0,0 +1,246 @@
+package main
+
+import (
+	"book-service/internal/data"
+	"book-service/internal/domain"
+	"book-service/internal/filters"
+	"book-service/internal/validator"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// streamHeartbeat keeps idle event streams from being closed by proxies
+const streamHeartbeat = 25 * time.Second
+
+func (app *application) listNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	var input struct {
+		Unread bool
+		filters.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Unread = app.readString(qs, "unread", "false") == "true"
+	input.Filters.Page = app.readInt(qs, "page", 1, v)
+	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
+
+	// notifications are always newest first, the sort only has to pass validation
+	input.Filters.Sort = "-created_at"
+	input.Filters.SortSafelist = []string{"-created_at"}
+
+	if filters.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	notifications, metadata, err := app.models.Notification.GetAllForUser(user.ID, input.Unread, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"notifications": notifications, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showUnreadNotificationCountHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	count, err := app.models.Notification.UnreadCount(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"unread": count}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) markNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	var input struct {
+		IDs []int64 `json:"ids"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(len(input.IDs) > 0, "ids", "must contain at least one id")
+	v.Check(len(input.IDs) <= 100, "ids", "must not contain more than 100 ids")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	marked, err := app.models.Notification.MarkRead(user.ID, input.IDs)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"marked": marked}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) markAllNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	marked, err := app.models.Notification.MarkAllRead(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"marked": marked}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// streamTokenTTL is how long a stream token can be used to open a stream.
+// An open stream is not closed when its token expires.
+const streamTokenTTL = time.Minute
+
+// createStreamTokenHandler issues a short-lived token that only opens the
+// notification stream, so the authentication token never appears in a URL
+func (app *application) createStreamTokenHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	token, err := app.models.Tokens.New(user.ID, streamTokenTTL, data.ScopeNotificationStream)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"stream_token": token}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// streamNotificationsHandler delivers new notifications as server-sent events.
+// Browsers' EventSource cannot set headers, so a token from POST
+// /notifications/stream-token may also be passed in the token query
+// parameter. Authentication tokens are not accepted there, as URLs end up in
+// logs and browser history.
+func (app *application) streamNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	if user.IsAnonymous() {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			app.authenticationRequiredResponse(w, r)
+			return
+		}
+
+		v := validator.New()
+		if data.ValidateTokenPlaintext(v, token); !v.Valid() {
+			app.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+
+		var err error
+		user, err = app.models.Users.GetForToken(data.ScopeNotificationStream, token)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.invalidAuthenticationTokenResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
+
+	unread, err := app.models.Notification.UnreadCount(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	notifications, unsubscribe := app.broker.Subscribe(user.ID)
+	defer unsubscribe()
+
+	// the stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	err = rc.SetWriteDeadline(time.Time{})
+	if err != nil {
+		app.logError(r, err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	err = writeEvent(w, "unread", 0, envelope{"unread": unread})
+	if err == nil {
+		err = rc.Flush()
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for err == nil {
+		select {
+		case <-r.Context().Done():
+			return
+		case notification, ok := <-notifications:
+			if !ok {
+				return
+			}
+			err = writeEvent(w, "notification", notification.ID, envelope{"notification": notification})
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		}
+
+		if err == nil {
+			err = rc.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event string, id int64, data envelope) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if id > 0 {
+		_, err = fmt.Fprintf(w, "id: %d\n", id)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, js)
+	return err
+}
+
+// publish hands an event to the notification publisher, which decides who
+// to notify and delivers the notifications in the background
+func (app *application) publish(event *domain.NotificationEvent) {
+	if app.notifier == nil {
+		return
+	}
+	app.notifier.Publish(event)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"book-service/internal/data"
+	"book-service/internal/domain"
+	"book-service/internal/validator"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+func (app *application) listBookPricesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	editionID := app.readInt(r.URL.Query(), "edition_id", 0, v)
+	v.Check(editionID >= 0, "edition_id", "must not be negative")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = app.models.Book.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	prices, err := app.models.Price.GetForBook(id, int64(editionID))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"prices": prices}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// importPricesHandler takes the prices seen by one scrape of the storefront
+func (app *application) importPricesHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Source string                     `json:"source"`
+		Prices []*domain.PriceObservation `json:"prices"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Source == "" {
+		input.Source = data.PriceSourceImport
+	}
+
+	v := validator.New()
+	if data.ValidatePriceImport(v, input.Source, input.Prices); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	result, err := app.models.Price.Import(input.Source, input.Prices)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.checkPriceAlerts(result.BookIDs...)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"import": result}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateFavoritePriceAlertHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Below *float64 `json:"below"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.Below != nil, "below", "must be provided")
+	if data.ValidatePriceAlert(v, input.Below); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	app.setFavoritePriceAlert(w, r, id, input.Below)
+}
+
+func (app *application) deleteFavoritePriceAlertHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	app.setFavoritePriceAlert(w, r, id, nil)
+}
+
+func (app *application) setFavoritePriceAlert(w http.ResponseWriter, r *http.Request, id int64, below *float64) {
+	user := app.contextGetUser(r)
+
+	favoriteBook, err := app.models.FavoriteBook.Get(id)
+	if err == nil && favoriteBook.UserID != user.ID {
+		err = data.ErrRecordNotFound
+	}
+	if err == nil {
+		favoriteBook.PriceAlertBelow = below
+		err = app.models.FavoriteBook.SetPriceAlert(favoriteBook)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"favorite_book": favoriteBook}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// checkPriceAlerts fires the price-drop alerts of the books in the background,
+// once the price changes that may have reached them are committed
+func (app *application) checkPriceAlerts(bookIDs ...int64) {
+	if len(bookIDs) == 0 {
+		return
+	}
+
+	app.background(func() {
+		for _, bookID := range bookIDs {
+			alerts, err := app.models.Price.TriggerAlerts(bookID)
+			if err != nil {
+				app.logger.PrintError(err, map[string]string{"book_id": fmt.Sprint(bookID)})
+				continue
+			}
+			for _, alert := range alerts {
+				app.notifyPriceDrop(alert)
+			}
+		}
+	})
+}
+
+func (app *application) notifyPriceDrop(alert *domain.PriceAlert) {
+	app.publish(&domain.NotificationEvent{
+		Type:      domain.NotificationPriceDrop,
+		UserID:    alert.UserID,
+		BookID:    alert.BookID,
+		SubjectID: alert.FavoriteID,
+		Message:   fmt.Sprintf("%s dropped to %.2f, below your alert at %.2f", alert.Title, alert.Amount, alert.Threshold),
+	})
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"book-service/internal/data"
+	"book-service/internal/validator"
+	"errors"
+	"net/http"
+	"time"
+)
+
+func (app *application) showGenreTopHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	limit, ok := app.readRankingLimit(w, r)
+	if !ok {
+		return
+	}
+
+	genre, err := app.models.Genre.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	books, err := app.models.Ranking.GetGenreTop(genre.Title, limit)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"genre": genre, "top_books": books}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showSubGenreTopHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	limit, ok := app.readRankingLimit(w, r)
+	if !ok {
+		return
+	}
+
+	subGenre, err := app.models.SubGenre.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	books, err := app.models.Ranking.GetSubGenreTop(subGenre.MainGenre, subGenre.Title, limit)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"sub_genre": subGenre, "top_books": books}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showTrendingHandler(w http.ResponseWriter, r *http.Request) {
+	limit, ok := app.readRankingLimit(w, r)
+	if !ok {
+		return
+	}
+
+	window := app.readString(r.URL.Query(), "window", "week")
+
+	v := validator.New()
+	if _, exists := data.TrendingWindows[window]; !exists {
+		v.AddError("window", "must be one of day, week or month")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	books, err := app.models.Ranking.GetTrending(window, limit)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"window": window, "trending": books}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readRankingLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := validator.New()
+
+	limit := app.readInt(r.URL.Query(), "limit", 20, v)
+	v.Check(limit > 0, "limit", "must be greater than zero")
+	v.Check(limit <= data.RankingSize, "limit", "must be a maximum of 100")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return 0, false
+	}
+
+	return limit, true
+}
+
+// startRankingJob recomputes the book rankings now and then on every tick of
+// the configured interval, until stopRankings is called on shutdown.
+func (app *application) startRankingJob() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	app.stopRankings = func() {
+		close(stop)
+		<-done
+	}
+
+	app.background(func() {
+		defer close(done)
+
+		ticker := time.NewTicker(app.config.rankings.interval)
+		defer ticker.Stop()
+
+		for {
+			start := time.Now()
+			err := app.models.Ranking.Recompute()
+			if err != nil {
+				app.logger.PrintError(err, map[string]string{"job": "rankings"})
+			} else {
+				app.logger.PrintInfo("rankings recomputed", map[string]string{
+					"job":      "rankings",
+					"duration": time.Since(start).String(),
+				})
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	})
+}
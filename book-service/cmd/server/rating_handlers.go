@@ -3,6 +3,7 @@ package main
 import (
 	"book-service/internal/data"
 	"book-service/internal/domain"
+	"book-service/internal/recommend"
 	"book-service/internal/validator"
 	"errors"
 	"fmt"
@@ -75,6 +76,19 @@ func (app *application) createRatingHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	app.recordActivity(r, &domain.ActivityEvent{
+		UserID:    rating.UserID,
+		Type:      domain.ActivityRating,
+		BookID:    &rating.BookID,
+		SubjectID: rating.ID,
+	})
+	app.trackInteraction(r, recommend.Event{
+		UserID: rating.UserID,
+		BookID: rating.BookID,
+		Type:   recommend.EventRate,
+		Rating: float64(rating.Score),
+	})
+
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/ratings/%d", rating.ID))
 
@@ -213,6 +227,13 @@ func (app *application) updateRatingHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	app.trackInteraction(r, recommend.Event{
+		UserID: rating.UserID,
+		BookID: rating.BookID,
+		Type:   recommend.EventRate,
+		Rating: float64(rating.Score),
+	})
+
 	err = app.writeJSON(w, http.StatusOK, envelope{"rating": rating}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -286,7 +307,7 @@ func (app *application) listBookRatingsHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	_, err = app.models.Book.Get(bookID)
+	book, err := app.models.Book.Get(bookID)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -309,11 +330,26 @@ func (app *application) listBookRatingsHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	histogram, err := app.models.Rating.GetDistribution(bookID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	genreMean, err := app.models.Book.GetGenreMeanRating(book.MainGenre)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	response := envelope{
 		"ratings": ratings,
 		"summary": map[string]interface{}{
-			"average": avgRating,
-			"count":   count,
+			"average":    avgRating,
+			"count":      count,
+			"histogram":  histogram,
+			"weighted":   data.BayesianRating(avgRating, int64(count), genreMean),
+			"genre_mean": genreMean,
 		},
 	}
 
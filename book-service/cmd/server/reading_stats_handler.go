@@ -0,0 +1,100 @@
+package main
+
+import (
+	"book-service/internal/data"
+	"book-service/internal/domain"
+	"book-service/internal/validator"
+	"errors"
+	"net/http"
+	"time"
+)
+
+func (app *application) showReadingStatsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	v := validator.New()
+
+	now := time.Now()
+	year := app.readInt(r.URL.Query(), "year", now.Year(), v)
+	v.Check(year >= 1900 && year <= 3000, "year", "must be a valid year")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	stats, err := app.models.ReadingStats.GetForUser(user.ID, year)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	goal, err := app.models.ReadingGoal.GetForYear(user.ID, year)
+	switch {
+	case err == nil:
+		stats.Goal = data.CalculateGoalProgress(goal, stats.BooksFinished, now)
+	case !errors.Is(err, data.ErrRecordNotFound):
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"stats": stats}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listReadingGoalsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	goals, err := app.models.ReadingGoal.GetAllForUser(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"reading_goals": goals}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateReadingGoalHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	var input struct {
+		Year   *int `json:"year"`
+		Target int  `json:"target"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	goal := &domain.ReadingGoal{
+		UserID: user.ID,
+		Year:   time.Now().Year(),
+		Target: input.Target,
+	}
+	if input.Year != nil {
+		goal.Year = *input.Year
+	}
+
+	v := validator.New()
+	if data.ValidateReadingGoal(v, goal); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.ReadingGoal.Upsert(goal)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"reading_goal": goal}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
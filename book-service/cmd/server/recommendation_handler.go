@@ -0,0 +1,88 @@
+package main
+
+import (
+	"book-service/internal/recommend"
+	"book-service/internal/validator"
+	"errors"
+	"net/http"
+)
+
+// submitOnboardingHandler seeds the user's recommendation preferences from
+// the onboarding questionnaire. The recommendation service only accepts it
+// from us, so users cannot seed preferences for someone else.
+func (app *application) submitOnboardingHandler(w http.ResponseWriter, r *http.Request) {
+	if app.recommender == nil {
+		app.recommendationsUnavailableResponse(w, r)
+		return
+	}
+
+	var input recommend.Onboarding
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	prefs, err := app.recommender.SubmitOnboarding(r.Context(), user.ID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, recommend.ErrRejected):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"preferences": prefs}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// recommendationClickHandler counts the user's click on a recommended book for
+// the experiment they take part in
+func (app *application) recommendationClickHandler(w http.ResponseWriter, r *http.Request) {
+	if app.recommender == nil {
+		app.recommendationsUnavailableResponse(w, r)
+		return
+	}
+
+	var input struct {
+		BookID int64 `json:"book_id"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	if v.Check(input.BookID > 0, "book_id", "must be provided"); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	assignment, err := app.recommender.LogClick(r.Context(), app.contextGetUser(r).ID, input.BookID)
+	if err != nil {
+		switch {
+		case errors.Is(err, recommend.ErrNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, recommend.ErrRejected):
+			v.AddError("book_id", "was not recommended to you")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"experiment": assignment}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
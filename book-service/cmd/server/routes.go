@@ -1,6 +1,7 @@
 package main
 
 import (
+	"book-service/internal/storage"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
@@ -12,17 +13,30 @@ func (app *application) routes() http.Handler {
 	router.NotFound = http.HandlerFunc(app.notFoundResponse)
 	router.MethodNotAllowed = http.HandlerFunc(app.methodNotAllowedResponse)
 
-	router.HandlerFunc(http.MethodGet, "/Books", app.listBooksHandler)                                               ///
-	router.HandlerFunc(http.MethodPost, "/Books", app.requirePermission("movies:write", app.createBookHandler))      ////
-	router.HandlerFunc(http.MethodGet, "/Books/:id", app.showBookHandler)                                            ////
+	router.HandlerFunc(http.MethodGet, "/Books", app.listBooksHandler)                                          ///
+	router.HandlerFunc(http.MethodPost, "/Books", app.requirePermission("movies:write", app.createBookHandler)) ////
+	router.HandlerFunc(http.MethodGet, "/Books/:id", app.showBookHandler)                                       ////
+	router.HandlerFunc(http.MethodGet, "/Books/:id/details", app.showBookDetailsHandler)
+	router.HandlerFunc(http.MethodGet, "/Books/:id/editions", app.listBookEditionsHandler)
+	router.HandlerFunc(http.MethodGet, "/Books/:id/prices", app.listBookPricesHandler)
+	router.HandlerFunc(http.MethodPost, "/Books/:id/purchase-click", app.requireAuthenticatedUser(app.purchaseClickHandler))
+	router.HandlerFunc(http.MethodPost, "/prices", app.requirePermission("movies:write", app.importPricesHandler))
+	router.HandlerFunc(http.MethodPost, "/Books/:id/editions", app.requirePermission("movies:write", app.createEditionHandler))
+	router.HandlerFunc(http.MethodPatch, "/editions/:id", app.requirePermission("movies:write", app.updateEditionHandler))
+	router.HandlerFunc(http.MethodDelete, "/editions/:id", app.requirePermission("movies:write", app.deleteEditionHandler))
 	router.HandlerFunc(http.MethodPatch, "/Books/:id", app.requirePermission("movies:write", app.updateBookHandler)) ///
 	router.HandlerFunc(http.MethodDelete, "/Books/:id", app.deleteBookHandler)                                       ////
+	router.HandlerFunc(http.MethodPut, "/Books/:id/cover", app.requirePermission("movies:write", app.uploadCoverHandler))
+	router.HandlerFunc(http.MethodDelete, "/Books/:id/cover", app.requirePermission("movies:write", app.deleteCoverHandler))
 
 	router.HandlerFunc(http.MethodGet, "/Genres", app.listGenreHandler)          ///
 	router.HandlerFunc(http.MethodPost, "/Genres", app.createGenreHandler)       ///
 	router.HandlerFunc(http.MethodGet, "/Genres/:id", app.showGenreHandler)      ////
 	router.HandlerFunc(http.MethodPatch, "/Genres/:id", app.updateGenreHandler)  ////
 	router.HandlerFunc(http.MethodDelete, "/Genres/:id", app.deleteGenreHandler) ///
+	router.HandlerFunc(http.MethodGet, "/Genres/:id/lists", app.listGenreFeaturedListsHandler)
+	router.HandlerFunc(http.MethodGet, "/Genres/:id/top", app.showGenreTopHandler)
+	router.HandlerFunc(http.MethodGet, "/genres/tree", app.showGenreTreeHandler)
 
 	router.HandlerFunc(http.MethodGet, "/SubGenres", app.listSubGenresHandler)                              ////
 	router.HandlerFunc(http.MethodPost, "/SubGenres", app.createSubGenreHandler)                            ////
@@ -30,12 +44,24 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodPatch, "/SubGenres/:id", app.updateSubGenreHandler)                       ///
 	router.HandlerFunc(http.MethodDelete, "/SubGenres/:id", app.deleteSubGenreHandler)                      ////
 	router.HandlerFunc(http.MethodGet, "/Genre/:main_genre/SubGenres", app.showSubGenresByMainGenreHandler) ///
+	router.HandlerFunc(http.MethodGet, "/SubGenres/:id/top", app.showSubGenreTopHandler)
+	router.HandlerFunc(http.MethodPut, "/SubGenres/:id/parent", app.requirePermission("movies:write", app.moveSubGenreHandler))
+	router.HandlerFunc(http.MethodGet, "/trending", app.showTrendingHandler)
+
+	router.HandlerFunc(http.MethodGet, "/Authors", app.listAuthorsHandler)
+	router.HandlerFunc(http.MethodGet, "/Authors/:id", app.showAuthorHandler)
+	router.HandlerFunc(http.MethodPatch, "/Authors/:id", app.requirePermission("movies:write", app.updateAuthorHandler))
+	router.HandlerFunc(http.MethodGet, "/Authors/:id/books", app.listAuthorBooksHandler)
+	router.HandlerFunc(http.MethodPost, "/Authors/:id/follow", app.requireAuthenticatedUser(app.followAuthorHandler))
+	router.HandlerFunc(http.MethodDelete, "/Authors/:id/follow", app.requireAuthenticatedUser(app.unfollowAuthorHandler))
 
 	router.HandlerFunc(http.MethodPost, "/Comments", app.createCommentHandler)            ///
 	router.HandlerFunc(http.MethodGet, "/Comments/:id", app.showCommentHandler)           ///
 	router.HandlerFunc(http.MethodPatch, "/Comments/:id", app.updateCommentHandler)       ///
 	router.HandlerFunc(http.MethodDelete, "/Comments/:id", app.deleteCommentHandler)      ///
 	router.HandlerFunc(http.MethodGet, "/booksComments/:id", app.listBookCommentsHandler) ///
+	router.HandlerFunc(http.MethodPost, "/Comments/:id/like", app.requireAuthenticatedUser(app.likeCommentHandler))
+	router.HandlerFunc(http.MethodDelete, "/Comments/:id/like", app.requireAuthenticatedUser(app.unlikeCommentHandler))
 
 	router.HandlerFunc(http.MethodPost, "/Ratings", app.createRatingHandler)                  ////
 	router.HandlerFunc(http.MethodGet, "/Ratings/:id", app.showRatingHandler)                 ///
@@ -48,13 +74,71 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodPut, "/users/activated", app.activateUserHandler)
 	router.HandlerFunc(http.MethodPost, "/tokens/authentication", app.createAuthenticationTokenHandler)
 	router.HandlerFunc(http.MethodPut, "/users/profile", app.requireAuthenticatedUser(app.updateUserProfileHandler))
+	router.HandlerFunc(http.MethodPut, "/users/privacy", app.requireAuthenticatedUser(app.updatePrivacySettingsHandler))
+	router.HandlerFunc(http.MethodGet, "/users/:id", app.showUserProfileHandler)
+
+	router.HandlerFunc(http.MethodPost, "/users/:id/follow", app.requireAuthenticatedUser(app.followUserHandler))
+	router.HandlerFunc(http.MethodDelete, "/users/:id/follow", app.requireAuthenticatedUser(app.unfollowUserHandler))
+	router.HandlerFunc(http.MethodGet, "/users/:id/followers", app.listFollowersHandler)
+	router.HandlerFunc(http.MethodGet, "/users/:id/following", app.listFollowingHandler)
+	router.HandlerFunc(http.MethodGet, "/feed", app.requireAuthenticatedUser(app.showFeedHandler))
+
+	router.HandlerFunc(http.MethodGet, "/notifications", app.requireAuthenticatedUser(app.listNotificationsHandler))
+	router.HandlerFunc(http.MethodGet, "/notifications/unread-count", app.requireAuthenticatedUser(app.showUnreadNotificationCountHandler))
+	router.HandlerFunc(http.MethodPost, "/notifications/read", app.requireAuthenticatedUser(app.markNotificationsReadHandler))
+	router.HandlerFunc(http.MethodPost, "/notifications/read-all", app.requireAuthenticatedUser(app.markAllNotificationsReadHandler))
+	router.HandlerFunc(http.MethodPost, "/notifications/stream-token", app.requireAuthenticatedUser(app.createStreamTokenHandler))
+	router.HandlerFunc(http.MethodGet, "/notifications/stream", app.streamNotificationsHandler)
 
 	router.HandlerFunc(http.MethodGet, "/favorite-books", app.requireAuthenticatedUser(app.GetFavoriteBooks))
 	router.HandlerFunc(http.MethodPost, "/favorite-books", app.requireAuthenticatedUser(app.addFavoriteBookHandler))
 	router.HandlerFunc(http.MethodDelete, "/favorite-books/:id", app.requireAuthenticatedUser(app.deleteFavoriteBookHandler))
+	router.HandlerFunc(http.MethodPut, "/favorite-books/:id/price-alert", app.requireAuthenticatedUser(app.updateFavoritePriceAlertHandler))
+	router.HandlerFunc(http.MethodDelete, "/favorite-books/:id/price-alert", app.requireAuthenticatedUser(app.deleteFavoritePriceAlertHandler))
+
+	router.HandlerFunc(http.MethodPost, "/recommendations/onboarding", app.requireAuthenticatedUser(app.submitOnboardingHandler))
+	router.HandlerFunc(http.MethodPost, "/recommendations/clicks", app.requireAuthenticatedUser(app.recommendationClickHandler))
+
+	router.HandlerFunc(http.MethodGet, "/reading-stats", app.requireAuthenticatedUser(app.showReadingStatsHandler))
+	router.HandlerFunc(http.MethodGet, "/reading-goals", app.requireAuthenticatedUser(app.listReadingGoalsHandler))
+	router.HandlerFunc(http.MethodPut, "/reading-goals", app.requireAuthenticatedUser(app.updateReadingGoalHandler))
+
+	router.HandlerFunc(http.MethodGet, "/lists", app.listBookListsHandler)
+	router.HandlerFunc(http.MethodPost, "/lists", app.requireAuthenticatedUser(app.createBookListHandler))
+	router.HandlerFunc(http.MethodGet, "/lists/:id", app.showBookListHandler)
+	router.HandlerFunc(http.MethodPatch, "/lists/:id", app.requireAuthenticatedUser(app.updateBookListHandler))
+	router.HandlerFunc(http.MethodDelete, "/lists/:id", app.requireAuthenticatedUser(app.deleteBookListHandler))
+	router.HandlerFunc(http.MethodPut, "/lists/:id/order", app.requireAuthenticatedUser(app.reorderBookListHandler))
+	router.HandlerFunc(http.MethodPut, "/lists/:id/feature", app.requirePermission("lists:feature", app.featureBookListHandler))
 
 	router.HandlerFunc(http.MethodGet, "/healthcheck", app.healthcheckHandler)
 
-	return app.enableCORS(app.recoverPanic(app.rateLimit(app.authenticate(router))))
+	// httprouter cannot register a static segment such as /Books/by-slug or
+	// /Books/isbn next to the /Books/:id wildcard, so these lookups have a router of
+	// their own that is tried first
+	lookups := httprouter.New()
+	lookups.HandlerFunc(http.MethodGet, "/Books/by-slug/:slug", app.showBookBySlugHandler)
+	lookups.HandlerFunc(http.MethodGet, "/Books/isbn/:isbn", app.showBookByISBNHandler)
+	lookups.HandlerFunc(http.MethodGet, "/Genres/by-slug/:slug", app.showGenreBySlugHandler)
+	lookups.HandlerFunc(http.MethodGet, "/SubGenres/by-slug/:slug", app.showSubGenreBySlugHandler)
+	lookups.HandlerFunc(http.MethodGet, "/Authors/by-slug/:slug", app.showAuthorBySlugHandler)
+
+	if app.config.storage.backend == "local" {
+		router.ServeFiles("/uploads/*filepath", storage.FileSystem(app.config.storage.dir))
+	}
+
+	return app.enableCORS(app.recoverPanic(app.rateLimit(app.authenticate(withLookups(lookups, router)))))
+
+}
 
+// withLookups serves the requests matching a route of lookups and passes the
+// others on to next
+func withLookups(lookups *httprouter.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handle, params, _ := lookups.Lookup(r.Method, r.URL.Path); handle != nil {
+			handle(w, r, params)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
 }
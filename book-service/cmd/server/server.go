@@ -21,6 +21,9 @@ func (app *application) serve() error {
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
+	// end the notification streams so they don't hold up the shutdown
+	srv.RegisterOnShutdown(app.broker.Close)
+
 	shutdownError := make(chan error)
 
 	go func() {
@@ -50,6 +53,11 @@ func (app *application) serve() error {
 		return err
 	}
 
+	app.notifier.Close()
+	if app.stopRankings != nil {
+		app.stopRankings()
+	}
+
 	app.logger.PrintInfo("stopped server", map[string]string{
 		"addr": srv.Addr,
 	})
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"book-service/internal/data"
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func (app *application) showBookBySlugHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := app.resolveSlugParam(w, r, data.SlugBooks, "/Books/by-slug/")
+	if !ok {
+		return
+	}
+
+	book, err := app.models.Book.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.Edition.AttachEditions(book)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"book": book}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showGenreBySlugHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := app.resolveSlugParam(w, r, data.SlugGenres, "/Genres/by-slug/")
+	if !ok {
+		return
+	}
+
+	genre, err := app.models.Genre.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"genre": genre}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showSubGenreBySlugHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := app.resolveSlugParam(w, r, data.SlugSubGenres, "/SubGenres/by-slug/")
+	if !ok {
+		return
+	}
+
+	subGenre, err := app.models.SubGenre.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"sub_genre": subGenre}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// resolveSlugParam looks up the :slug route parameter in table. When the slug
+// is an old one it answers with a permanent redirect to canonicalPrefix plus
+// the current slug, and ok is false.
+func (app *application) resolveSlugParam(w http.ResponseWriter, r *http.Request, table, canonicalPrefix string) (int64, bool) {
+	s := httprouter.ParamsFromContext(r.Context()).ByName("slug")
+
+	id, current, err := app.models.Slugs.Resolve(table, s)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return 0, false
+	}
+
+	if current != s {
+		location := canonicalPrefix + current
+		if r.URL.RawQuery != "" {
+			location += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, location, http.StatusMovedPermanently)
+		return 0, false
+	}
+
+	return id, true
+}
@@ -18,6 +18,7 @@ func (app *application) createSubGenreHandler(w http.ResponseWriter, r *http.Req
 		MainGenre string  `json:"main_genre"`
 		BookCount float64 `json:"book_count"`
 		URL       string  `json:"url"`
+		ParentID  *int64  `json:"parent_id"`
 	}
 
 	err := app.readJSON(w, r, &input)
@@ -33,6 +34,23 @@ func (app *application) createSubGenreHandler(w http.ResponseWriter, r *http.Req
 		MainGenre: input.MainGenre,
 		BookCount: input.BookCount,
 		URL:       input.URL,
+		ParentID:  input.ParentID,
+	}
+
+	// nested sub-genres always belong to the same genre as their parent
+	if input.ParentID != nil {
+		parent, err := app.models.SubGenre.Get(*input.ParentID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				v.AddError("parent_id", "must refer to an existing sub genre")
+				app.failedValidationResponse(w, r, v.Errors)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+		sgenre.MainGenre = parent.MainGenre
 	}
 
 	if data.ValidateSubGenre(v, sgenre); !v.Valid() {
@@ -143,6 +161,78 @@ func (app *application) updateSubGenreHandler(w http.ResponseWriter, r *http.Req
 	}
 }
 
+func (app *application) moveSubGenreHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// Version is the version the client last saw; without it the move only
+	// conflicts with writes made since the sub-genre was read here
+	var input struct {
+		GenreID  *int64 `json:"genre_id"`
+		ParentID *int64 `json:"parent_id"`
+		Version  *int32 `json:"version"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check((input.GenreID == nil) != (input.ParentID == nil), "parent", "exactly one of genre_id or parent_id must be provided")
+	v.Check(input.ParentID == nil || *input.ParentID != id, "parent_id", "must not be the sub genre itself")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	current, err := app.models.SubGenre.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if input.Version != nil && *input.Version != current.Version {
+		app.editConflictResponse(w, r)
+		return
+	}
+
+	subGenre, err := app.models.SubGenre.Move(id, current.Version, input.GenreID, input.ParentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		case errors.Is(err, data.ErrInvalidParent):
+			v.AddError("parent_id", err.Error())
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrRecordNotFound):
+			if input.ParentID != nil {
+				v.AddError("parent_id", "must refer to an existing sub genre")
+			} else {
+				v.AddError("genre_id", "must refer to an existing genre")
+			}
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"sub_genre": subGenre}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
 func (app *application) deleteSubGenreHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := app.readIDParam(w, r)
 	if err != nil {
@@ -207,7 +297,24 @@ func (app *application) showSubGenresByMainGenreHandler(w http.ResponseWriter, r
 
 	params := httprouter.ParamsFromContext(r.Context())
 	mainGenre := params.ByName("main_genre")
-	fmt.Print(mainGenre)
+
+	// accept a genre slug as well as the raw title
+	genreID, _, err := app.models.Slugs.Resolve(data.SlugGenres, mainGenre)
+	switch {
+	case err == nil:
+		genre, err := app.models.Genre.Get(genreID)
+		if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		if genre != nil {
+			mainGenre = genre.Title
+		}
+	case !errors.Is(err, data.ErrRecordNotFound):
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	subGenres, err := app.models.SubGenre.GetByGenre(mainGenre)
 	if err != nil {
 		switch {
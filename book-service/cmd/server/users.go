@@ -136,9 +136,12 @@ func (app *application) updateUserProfileHandler(w http.ResponseWriter, r *http.
 	}
 
 	var input struct {
-		Name     string  `json:"name"`
-		Email    string  `json:"email"`
-		Password *string `json:"password,omitempty"`
+		Name        string  `json:"name"`
+		Email       string  `json:"email"`
+		Password    *string `json:"password,omitempty"`
+		DisplayName *string `json:"display_name,omitempty"`
+		Bio         *string `json:"bio,omitempty"`
+		AvatarURL   *string `json:"avatar_url,omitempty"`
 	}
 
 	err := app.readJSON(w, r, &input)
@@ -150,6 +153,16 @@ func (app *application) updateUserProfileHandler(w http.ResponseWriter, r *http.
 	user.Name = input.Name
 	user.Email = input.Email
 
+	if input.DisplayName != nil {
+		user.DisplayName = *input.DisplayName
+	}
+	if input.Bio != nil {
+		user.Bio = *input.Bio
+	}
+	if input.AvatarURL != nil {
+		user.AvatarURL = *input.AvatarURL
+	}
+
 	if input.Password != nil {
 		err = user.Password.Set(*input.Password)
 		if err != nil {
@@ -183,3 +196,101 @@ func (app *application) updateUserProfileHandler(w http.ResponseWriter, r *http.
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+func (app *application) showUserProfileHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(w, r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	user, err := app.models.Users.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	viewer := app.contextGetUser(r)
+	isOwner := !viewer.IsAnonymous() && viewer.ID == user.ID
+
+	privacy, err := app.models.Profiles.GetPrivacy(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	counts, err := app.models.Profiles.GetCounts(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	favoriteBooks, err := app.models.FavoriteBook.GetAllForUser(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	profile := data.NewPublicProfile(user, privacy, counts, favoriteBooks, isOwner)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"profile": profile}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updatePrivacySettingsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	settings, err := app.models.Profiles.GetPrivacy(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		ShowBio      *bool `json:"show_bio"`
+		ShowAvatar   *bool `json:"show_avatar"`
+		ShowJoinDate *bool `json:"show_join_date"`
+		ShowStats    *bool `json:"show_stats"`
+		ShowShelves  *bool `json:"show_shelves"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.ShowBio != nil {
+		settings.ShowBio = *input.ShowBio
+	}
+	if input.ShowAvatar != nil {
+		settings.ShowAvatar = *input.ShowAvatar
+	}
+	if input.ShowJoinDate != nil {
+		settings.ShowJoinDate = *input.ShowJoinDate
+	}
+	if input.ShowStats != nil {
+		settings.ShowStats = *input.ShowStats
+	}
+	if input.ShowShelves != nil {
+		settings.ShowShelves = *input.ShowShelves
+	}
+
+	err = app.models.Profiles.UpsertPrivacy(settings)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"privacy": settings}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
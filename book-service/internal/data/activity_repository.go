@@ -0,0 +1,86 @@
+package data
+
+import (
+	"book-service/internal/domain"
+	"book-service/internal/filters"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ActivityModel struct {
+	DB *sql.DB
+}
+
+// Insert records an activity event. Favorites only know the book title, so when
+// BookID is not set the book is looked up by BookTitle.
+func (m ActivityModel) Insert(event *domain.ActivityEvent) error {
+	query := `
+		INSERT INTO activity_events (user_id, event_type, book_id, subject_id)
+		VALUES ($1, $2, COALESCE($3::bigint, (SELECT id FROM books WHERE title = $4 ORDER BY id LIMIT 1)), $5)
+		RETURNING id, book_id, created_at`
+
+	args := []interface{}{event.UserID, event.Type, event.BookID, event.BookTitle, event.SubjectID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&event.ID, &event.BookID, &event.CreatedAt)
+}
+
+// GetFeed returns the activity of every user that userID follows, newest first
+func (m ActivityModel) GetFeed(userID int64, mfilters filters.Filters) ([]*domain.ActivityEvent, filters.Metadata, error) {
+	query := `
+		SELECT count(*) OVER(), e.id, e.user_id, COALESCE(NULLIF(u.display_name, ''), u.name), e.event_type, e.book_id, COALESCE(b.title, ''),
+			e.subject_id, r.score, c.content, e.created_at
+		FROM activity_events e
+		INNER JOIN follows f ON f.followee_id = e.user_id
+		INNER JOIN users u ON u.id = e.user_id
+		LEFT JOIN books b ON b.id = e.book_id
+		LEFT JOIN ratings r ON e.event_type = 'rating' AND r.id = e.subject_id
+		LEFT JOIN comments c ON e.event_type = 'comment' AND c.id = e.subject_id
+		WHERE f.follower_id = $1
+		ORDER BY e.created_at DESC, e.id DESC
+		LIMIT $2 OFFSET $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, mfilters.Limit(), mfilters.Offset())
+	if err != nil {
+		return nil, filters.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	events := []*domain.ActivityEvent{}
+
+	for rows.Next() {
+		var event domain.ActivityEvent
+		err := rows.Scan(
+			&totalRecords,
+			&event.ID,
+			&event.UserID,
+			&event.UserName,
+			&event.Type,
+			&event.BookID,
+			&event.BookTitle,
+			&event.SubjectID,
+			&event.Score,
+			&event.Content,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, filters.Metadata{}, err
+		}
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, filters.Metadata{}, err
+	}
+
+	metadata := filters.CalculateMetadata(totalRecords, mfilters.Page, mfilters.PageSize)
+
+	return events, metadata, nil
+}
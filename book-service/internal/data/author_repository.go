@@ -0,0 +1,388 @@
+package data
+
+import (
+	"book-service/internal/domain"
+	"book-service/internal/filters"
+	"book-service/internal/validator"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrDuplicateAuthor = errors.New("another author already uses this name")
+)
+
+var (
+	authorSeparatorRX = regexp.MustCompile(`\s*[,&]\s*`)
+	// authorSuffixRX matches the parts of a name that follow a comma but do
+	// not start a new name, as in "Martin Luther King, Jr."
+	authorSuffixRX = regexp.MustCompile(`(?i)^(jr|sr|ph\.?\s?d|m\.?d|ii|iii|iv)\.?$`)
+)
+
+// SplitAuthors splits a book's author credit such as "A, B" or "A & B" into
+// the individual names.
+func SplitAuthors(credit string) []string {
+	names := []string{}
+	for _, name := range authorSeparatorRX.Split(credit, -1) {
+		name = strings.TrimSpace(name)
+		switch {
+		case AuthorNameKey(name) == "":
+		case authorSuffixRX.MatchString(name) && len(names) > 0:
+			names[len(names)-1] += ", " + name
+		default:
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// AuthorNameKey reduces a name to lowercase letters and digits so spellings
+// like "J.K. Rowling" and "J. K. Rowling" belong to the same author. Letters
+// of any script are kept, so "García" and "Толстой" have keys of their own.
+func AuthorNameKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+type AuthorModel struct {
+	DB *sql.DB
+}
+
+const authorColumns = `
+	a.id, a.name, a.slug, a.bio,
+	COALESCE((SELECT array_agg(al.alias ORDER BY al.alias) FROM author_aliases al WHERE al.author_id = a.id), '{}'),
+	(SELECT count(*) FROM book_authors ba WHERE ba.author_id = a.id) AS book_count,
+	a.created_at, a.version`
+
+func scanAuthor(scanner interface{ Scan(...interface{}) error }, author *domain.Author, extra ...interface{}) error {
+	dest := append(extra,
+		&author.ID,
+		&author.Name,
+		&author.Slug,
+		&author.Bio,
+		pq.Array(&author.Aliases),
+		&author.BookCount,
+		&author.CreatedAt,
+		&author.Version,
+	)
+	return scanner.Scan(dest...)
+}
+
+func (m AuthorModel) Get(id int64) (*domain.Author, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `SELECT ` + authorColumns + `
+		FROM authors a
+		WHERE a.id = $1`
+
+	var author domain.Author
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := scanAuthor(m.DB.QueryRowContext(ctx, query, id), &author)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &author, nil
+}
+
+// Update saves the author's name, bio and aliases. The name is always kept as
+// one of the aliases, and renaming the author gives them a new slug.
+func (m AuthorModel) Update(author *domain.Author) error {
+	return retrySlugConflict(&author.Slug, func() error {
+		return m.update(author)
+	})
+}
+
+func (m AuthorModel) update(author *domain.Author) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	author.Slug, err = renameSlug(ctx, tx, SlugAuthors, author.ID, author.Slug, author.Name)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE authors
+		SET name = $1, name_key = $2, slug = $3, bio = $4, version = version + 1
+		WHERE id = $5 AND version = $6
+		RETURNING version`
+
+	args := []interface{}{author.Name, AuthorNameKey(author.Name), author.Slug, author.Bio, author.ID, author.Version}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&author.Version)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		case isSlugConflict(err):
+			return err
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			return ErrDuplicateAuthor
+		default:
+			return err
+		}
+	}
+
+	aliases := []string{author.Name}
+	for _, alias := range author.Aliases {
+		if alias = strings.TrimSpace(alias); alias != "" && alias != author.Name {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM author_aliases WHERE author_id = $1`, author.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, alias := range aliases {
+		query = `
+			INSERT INTO author_aliases (author_id, alias, name_key)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING`
+
+		_, err = tx.ExecContext(ctx, query, author.ID, alias, AuthorNameKey(alias))
+		if err != nil {
+			return err
+		}
+	}
+	author.Aliases = aliases
+
+	return tx.Commit()
+}
+
+// GetAll searches authors by name and by any of their aliases
+func (m AuthorModel) GetAll(search string, mfilters filters.Filters) ([]*domain.Author, filters.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), `+authorColumns+`
+		FROM authors a
+		WHERE ($1 = ''
+			OR to_tsvector('simple', a.name) @@ plainto_tsquery('simple', $1)
+			OR a.name ILIKE '%%' || $1 || '%%'
+			OR EXISTS (SELECT 1 FROM author_aliases al WHERE al.author_id = a.id AND (al.alias ILIKE '%%' || $1 || '%%' OR al.name_key = $2)))
+		ORDER BY %s %s, a.id ASC
+		LIMIT $3 OFFSET $4`, mfilters.SortColumn(), mfilters.SortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, search, AuthorNameKey(search), mfilters.Limit(), mfilters.Offset())
+	if err != nil {
+		return nil, filters.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	authors := []*domain.Author{}
+
+	for rows.Next() {
+		var author domain.Author
+		err := scanAuthor(rows, &author, &totalRecords)
+		if err != nil {
+			return nil, filters.Metadata{}, err
+		}
+		authors = append(authors, &author)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, filters.Metadata{}, err
+	}
+
+	metadata := filters.CalculateMetadata(totalRecords, mfilters.Page, mfilters.PageSize)
+
+	return authors, metadata, nil
+}
+
+// GetBooks returns the books crediting an author
+func (m AuthorModel) GetBooks(authorID int64, mfilters filters.Filters) ([]*domain.Book, filters.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), `+bookColumns+`
+		FROM book_authors ba
+		INNER JOIN books ON books.id = ba.book_id
+		WHERE ba.author_id = $1
+		ORDER BY books.%s %s NULLS LAST, books.id ASC
+		LIMIT $2 OFFSET $3`, mfilters.SortColumn(), mfilters.SortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, authorID, mfilters.Limit(), mfilters.Offset())
+	if err != nil {
+		return nil, filters.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	books := []*domain.Book{}
+
+	for rows.Next() {
+		var book domain.Book
+		err := scanBook(rows, &book, &totalRecords)
+		if err != nil {
+			return nil, filters.Metadata{}, err
+		}
+		books = append(books, &book)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, filters.Metadata{}, err
+	}
+
+	metadata := filters.CalculateMetadata(totalRecords, mfilters.Page, mfilters.PageSize)
+
+	return books, metadata, nil
+}
+
+// linkBookAuthors credits a book to the authors named in its author text,
+// creating authors that do not exist yet.
+func linkBookAuthors(ctx context.Context, q queryer, bookID int64, credit string) error {
+	_, err := q.ExecContext(ctx, `DELETE FROM book_authors WHERE book_id = $1`, bookID)
+	if err != nil {
+		return err
+	}
+
+	for position, name := range SplitAuthors(credit) {
+		key := AuthorNameKey(name)
+
+		var authorID int64
+		query := `
+			SELECT id FROM (
+				SELECT id, 0 AS priority FROM authors WHERE name_key = $1
+				UNION ALL
+				SELECT author_id, 1 FROM author_aliases WHERE name_key = $1
+			) found
+			ORDER BY priority, id
+			LIMIT 1`
+
+		err = q.QueryRowContext(ctx, query, key).Scan(&authorID)
+		if errors.Is(err, sql.ErrNoRows) {
+			var slug string
+			slug, err = uniqueSlug(ctx, q, SlugAuthors, name, 0)
+			if err != nil {
+				return err
+			}
+
+			query = `
+				INSERT INTO authors (name, name_key, slug)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (name_key) DO UPDATE SET name_key = EXCLUDED.name_key
+				RETURNING id`
+
+			err = q.QueryRowContext(ctx, query, name, key, slug).Scan(&authorID)
+		}
+		if err != nil {
+			return err
+		}
+
+		query = `
+			INSERT INTO author_aliases (author_id, alias, name_key)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING`
+
+		_, err = q.ExecContext(ctx, query, authorID, name, key)
+		if err != nil {
+			return err
+		}
+
+		query = `
+			INSERT INTO book_authors (book_id, author_id, position)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING`
+
+		_, err = q.ExecContext(ctx, query, bookID, authorID, position+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Follow subscribes the user to the author's new books. Following an author
+// twice is not an error.
+func (m AuthorModel) Follow(userID, authorID int64) error {
+	query := `
+		INSERT INTO author_follows (user_id, author_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, author_id) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, userID, authorID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrRecordNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (m AuthorModel) Unfollow(userID, authorID int64) error {
+	query := `
+		DELETE FROM author_follows
+		WHERE user_id = $1 AND author_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userID, authorID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func ValidateAuthor(v *validator.Validator, author *domain.Author) {
+	v.Check(author.Name != "", "name", "must be provided")
+	v.Check(AuthorNameKey(author.Name) != "", "name", "must contain letters or digits")
+	v.Check(len(author.Name) <= 200, "name", "must not be more than 200 bytes long")
+	v.Check(len(author.Bio) <= 5000, "bio", "must not be more than 5000 bytes long")
+	v.Check(len(author.Aliases) <= 50, "aliases", "must not contain more than 50 names")
+	for _, alias := range author.Aliases {
+		v.Check(len(alias) <= 200, "aliases", "must not contain names more than 200 bytes long")
+	}
+}
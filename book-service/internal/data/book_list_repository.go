@@ -0,0 +1,328 @@
+package data
+
+import (
+	"book-service/internal/domain"
+	"book-service/internal/filters"
+	"book-service/internal/validator"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrUnknownBook = errors.New("references a book that does not exist")
+)
+
+type BookListModel struct {
+	DB *sql.DB
+}
+
+const bookListColumns = `
+	l.id, l.user_id, l.title, l.description, l.is_public, l.is_featured, l.featured_genre,
+	COALESCE((SELECT array_agg(i.book_id ORDER BY i.position) FROM book_list_items i WHERE i.list_id = l.id), '{}'),
+	l.created_at, l.updated_at, l.version`
+
+func scanBookList(scanner interface{ Scan(...interface{}) error }, list *domain.BookList, extra ...interface{}) error {
+	dest := append(extra,
+		&list.ID,
+		&list.UserID,
+		&list.Title,
+		&list.Description,
+		&list.IsPublic,
+		&list.IsFeatured,
+		&list.FeaturedGenre,
+		pq.Array(&list.BookIDs),
+		&list.CreatedAt,
+		&list.UpdatedAt,
+		&list.Version,
+	)
+	return scanner.Scan(dest...)
+}
+
+// Insert creates the list together with its items
+func (m BookListModel) Insert(list *domain.BookList) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO book_lists (user_id, title, description, is_public)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at, version`
+
+	args := []interface{}{list.UserID, list.Title, list.Description, list.IsPublic}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&list.ID, &list.CreatedAt, &list.UpdatedAt, &list.Version)
+	if err != nil {
+		return err
+	}
+
+	err = replaceBookListItems(ctx, tx, list.ID, list.BookIDs)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m BookListModel) Get(id int64) (*domain.BookList, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `SELECT ` + bookListColumns + `
+		FROM book_lists l
+		WHERE l.id = $1`
+
+	var list domain.BookList
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := scanBookList(m.DB.QueryRowContext(ctx, query, id), &list)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &list, nil
+}
+
+// Update saves the list fields and replaces its items with list.BookIDs, in that order
+func (m BookListModel) Update(list *domain.BookList) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE book_lists
+		SET title = $1, description = $2, is_public = $3, is_featured = $4, featured_genre = $5,
+			updated_at = NOW(), version = version + 1
+		WHERE id = $6 AND version = $7
+		RETURNING updated_at, version`
+
+	args := []interface{}{
+		list.Title,
+		list.Description,
+		list.IsPublic,
+		list.IsFeatured,
+		list.FeaturedGenre,
+		list.ID,
+		list.Version,
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&list.UpdatedAt, &list.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	err = replaceBookListItems(ctx, tx, list.ID, list.BookIDs)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceBookListItems(ctx context.Context, tx *sql.Tx, listID int64, bookIDs []int64) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM book_list_items WHERE list_id = $1`, listID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO book_list_items (list_id, book_id, position)
+		SELECT $1, t.book_id, t.position
+		FROM unnest($2::bigint[]) WITH ORDINALITY AS t(book_id, position)`
+
+	_, err = tx.ExecContext(ctx, query, listID, pq.Array(bookIDs))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrUnknownBook
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (m BookListModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM book_lists
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAll searches public lists by title and description. When ownerID is set
+// only that user's lists are returned, including private ones.
+func (m BookListModel) GetAll(search string, ownerID int64, mfilters filters.Filters) ([]*domain.BookList, filters.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), `+bookListColumns+`
+		FROM book_lists l
+		WHERE (to_tsvector('simple', l.title || ' ' || l.description) @@ plainto_tsquery('simple', $1) OR l.title ILIKE '%%' || $1 || '%%' OR $1 = '')
+		AND (($2 = 0 AND l.is_public) OR l.user_id = $2)
+		ORDER BY %s %s, l.id ASC
+		LIMIT $3 OFFSET $4`, mfilters.SortColumn(), mfilters.SortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, search, ownerID, mfilters.Limit(), mfilters.Offset())
+	if err != nil {
+		return nil, filters.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	lists := []*domain.BookList{}
+
+	for rows.Next() {
+		var list domain.BookList
+		err := scanBookList(rows, &list, &totalRecords)
+		if err != nil {
+			return nil, filters.Metadata{}, err
+		}
+		lists = append(lists, &list)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, filters.Metadata{}, err
+	}
+
+	metadata := filters.CalculateMetadata(totalRecords, mfilters.Page, mfilters.PageSize)
+
+	return lists, metadata, nil
+}
+
+// GetFeaturedForGenre returns the public lists pinned to a genre page
+func (m BookListModel) GetFeaturedForGenre(genre string) ([]*domain.BookList, error) {
+	query := `SELECT ` + bookListColumns + `
+		FROM book_lists l
+		WHERE l.is_featured AND l.is_public AND l.featured_genre = $1
+		ORDER BY l.updated_at DESC, l.id ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, genre)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []*domain.BookList{}
+
+	for rows.Next() {
+		var list domain.BookList
+		err := scanBookList(rows, &list)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, &list)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
+
+// GetBooks returns the books of a list in list order
+func (m BookListModel) GetBooks(listID int64) ([]*domain.Book, error) {
+	query := `
+		SELECT ` + bookColumns + `
+		FROM book_list_items i
+		INNER JOIN books ON books.id = i.book_id
+		WHERE i.list_id = $1
+		ORDER BY i.position`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanBooks(rows)
+}
+
+func ValidateBookList(v *validator.Validator, list *domain.BookList) {
+	v.Check(list.Title != "", "title", "must be provided")
+	v.Check(len(list.Title) <= 200, "title", "must not be more than 200 bytes long")
+	v.Check(len(list.Description) <= 2000, "description", "must not be more than 2000 bytes long")
+	v.Check(len(list.BookIDs) <= 500, "book_ids", "must not contain more than 500 books")
+	v.Check(!list.IsFeatured || list.FeaturedGenre != "", "featured_genre", "must be provided for featured lists")
+	v.Check(!list.IsFeatured || list.IsPublic, "is_public", "featured lists must be public")
+
+	seen := make(map[int64]bool, len(list.BookIDs))
+	for _, id := range list.BookIDs {
+		v.Check(id > 0, "book_ids", "must contain valid book ids")
+		v.Check(!seen[id], "book_ids", "must not contain duplicate values")
+		seen[id] = true
+	}
+}
+
+// SameBooks reports whether ids is a reordering of the books already in the list
+func SameBooks(list *domain.BookList, ids []int64) bool {
+	if len(ids) != len(list.BookIDs) {
+		return false
+	}
+	existing := make(map[int64]int, len(list.BookIDs))
+	for _, id := range list.BookIDs {
+		existing[id]++
+	}
+	for _, id := range ids {
+		if existing[id] == 0 {
+			return false
+		}
+		existing[id]--
+	}
+	return true
+}
@@ -3,6 +3,7 @@ package data
 import (
 	"book-service/internal/domain"
 	"book-service/internal/filters"
+	"book-service/internal/isbn"
 	"book-service/internal/validator"
 	"context"
 	"database/sql"
@@ -16,24 +17,149 @@ type BookModel struct {
 	DB *sql.DB
 }
 
-func (e BookModel) Insert(book *domain.Book) error {
-	query := `INSERT INTO books (title, author, main_genre, sub_genre, type, price, rating, people_rated, url) 
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-				RETURNING id, version`
+// genreMeansCTE computes the vote-weighted mean rating of every main genre
+const genreMeansCTE = `genre_means AS (
+		SELECT main_genre AS genre,
+			SUM(COALESCE(rating, 0)::float8 * COALESCE(people_rated, 0)) / NULLIF(SUM(COALESCE(people_rated, 0)), 0) AS mean
+		FROM books
+		GROUP BY main_genre
+	)`
+
+// weightedRatingExpr is the Bayesian average of a books row using its genre
+// mean from genre_means as the prior, see BayesianRating.
+var weightedRatingExpr = fmt.Sprintf(
+	`((%d * COALESCE(genre_means.mean, books.rating, 0) + COALESCE(books.rating, 0)::float8 * COALESCE(books.people_rated, 0)) / (%d + COALESCE(books.people_rated, 0)))`,
+	RatingPriorWeight, RatingPriorWeight)
+
+// bookColumns lists the books columns in the order scanBook expects them
+const bookColumns = `books.id, books.author, books.title, books.main_genre, books.sub_genre, books.type,
+	books.price, books.rating, books.people_rated, books.url, books.isbn10, books.isbn13, books.asin, books.slug, books.version,
+	books.cover`
+
+// scanBook scans a row selected with bookColumns, after any extra leading columns
+func scanBook(scanner interface{ Scan(...interface{}) error }, book *domain.Book, extra ...interface{}) error {
+	var cover []byte
+	dest := append(extra,
+		&book.ID,
+		&book.Author,
+		&book.Title,
+		&book.MainGenre,
+		&book.SubGenre,
+		&book.Type,
+		&book.Price,
+		&book.Rating,
+		&book.PeopleRated,
+		&book.URL,
+		&book.ISBN10,
+		&book.ISBN13,
+		&book.ASIN,
+		&book.Slug,
+		&book.Version,
+		&cover,
+	)
+	err := scanner.Scan(dest...)
+	if err != nil {
+		return err
+	}
+	book.Cover, err = decodeCover(cover)
+	return err
+}
+
+// scanBooks reads every row selected with bookColumns
+func scanBooks(rows *sql.Rows) ([]*domain.Book, error) {
+	defer rows.Close()
+
+	books := []*domain.Book{}
 
-	args := []interface{}{book.Author, book.Title, book.MainGenre, book.SubGenre, book.Type, book.Price, book.Rating, book.PeopleRated, book.URL}
+	for rows.Next() {
+		var book domain.Book
+		err := scanBook(rows, &book)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, &book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	return books, nil
+}
+
+// Insert creates the book with its primary edition, credits it to its authors
+// and starts its price history
+func (e BookModel) Insert(book *domain.Book) error {
+	return retrySlugConflict(&book.Slug, func() error {
+		return e.insert(book)
+	})
+}
+
+func (e BookModel) insert(book *domain.Book) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	return e.DB.QueryRowContext(ctx, query, args...).Scan(&book.ID, &book.Version)
+	tx, err := e.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	book.Slug, err = uniqueSlug(ctx, tx, SlugBooks, book.Title, 0)
+	if err != nil {
+		return err
+	}
+
+	completeIdentifiers(&book.ISBN10, &book.ISBN13, &book.ASIN, book.URL)
+
+	query := `INSERT INTO books (title, author, main_genre, sub_genre, type, price, rating, people_rated, url, isbn10, isbn13, asin, slug)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+				RETURNING id, version`
+
+	args := []interface{}{
+		book.Title,
+		book.Author,
+		book.MainGenre,
+		book.SubGenre,
+		book.Type,
+		book.Price,
+		book.Rating,
+		book.PeopleRated,
+		book.URL,
+		book.ISBN10,
+		book.ISBN13,
+		book.ASIN,
+		book.Slug,
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&book.ID, &book.Version)
+	if err != nil {
+		return err
+	}
+
+	err = linkBookAuthors(ctx, tx, book.ID, book.Author)
+	if err != nil {
+		return err
+	}
+
+	err = syncPrimaryEdition(ctx, tx, book)
+	if err != nil {
+		return err
+	}
+
+	err = recordPrices(ctx, tx, book.ID, PriceSourceEditor)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 func (e BookModel) Get(id int64) (*domain.Book, error) {
 	if id < 1 {
 		return nil, ErrRecordNotFound
 	}
 
-	query := `SELECT id,author, title, main_genre, sub_genre, type, price, rating, people_rated, url, version
+	query := `SELECT ` + bookColumns + `
 				FROM books
 				WHERE id = $1`
 	var book domain.Book
@@ -41,19 +167,7 @@ func (e BookModel) Get(id int64) (*domain.Book, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := e.DB.QueryRowContext(ctx, query, id).Scan(
-		&book.ID,
-		&book.Author,
-		&book.Title,
-		&book.MainGenre,
-		&book.SubGenre,
-		&book.Type,
-		&book.Price,
-		&book.Rating,
-		&book.PeopleRated,
-		&book.URL,
-		&book.Version,
-	)
+	err := scanBook(e.DB.QueryRowContext(ctx, query, id), &book)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -65,10 +179,87 @@ func (e BookModel) Get(id int64) (*domain.Book, error) {
 	return &book, nil
 }
 
+// GetByIdentifier finds the book with an edition matching an ISBN-10, ISBN-13
+// or ASIN. ISBNs match in either form.
+func (e BookModel) GetByIdentifier(code string) (*domain.Book, error) {
+	code = isbn.Normalize(code)
+
+	isbn10, isbn13, err := isbn.Parse(code)
+	if err != nil {
+		if !IsASIN(code) {
+			return nil, ErrRecordNotFound
+		}
+		isbn10, isbn13 = "", ""
+	}
+
+	query := `SELECT ` + bookColumns + `
+				FROM books
+				WHERE books.id = (
+					SELECT ed.book_id FROM editions ed
+					WHERE ($1 <> '' AND ed.isbn13 = $1) OR ($2 <> '' AND ed.isbn10 = $2) OR ed.asin = $3
+					ORDER BY ed.is_primary DESC, ed.id
+					LIMIT 1
+				)`
+	var book domain.Book
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err = scanBook(e.DB.QueryRowContext(ctx, query, isbn13, isbn10, code), &book)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &book, nil
+}
+
+// Update saves the book, its author credits and its primary edition, recording
+// any price change. Changing
+// the title gives the book a new slug and keeps the old one as a redirect.
 func (e BookModel) Update(book *domain.Book) error {
+	return retrySlugConflict(&book.Slug, func() error {
+		return e.update(book)
+	})
+}
+
+func (e BookModel) update(book *domain.Book) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := e.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	book.Slug, err = renameSlug(ctx, tx, SlugBooks, book.ID, book.Slug, book.Title)
+	if err != nil {
+		return err
+	}
+
+	// most updates, such as every new rating, leave the author alone and
+	// need not relink the book's authors
+	var previousAuthor string
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(author, '') FROM books WHERE id = $1`, book.ID).Scan(&previousAuthor)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	completeIdentifiers(&book.ISBN10, &book.ISBN13, &book.ASIN, book.URL)
+
 	query := `UPDATE books
-				SET title = $1, author = $2, main_genre = $3, sub_genre = $4, type = $5, price = $6, rating = $7, people_rated = $8, url = $9, version = version + 1
-				WHERE id = $10 AND version = $11
+				SET title = $1, author = $2, main_genre = $3, sub_genre = $4, type = $5, price = $6, rating = $7, people_rated = $8, url = $9,
+					isbn10 = $10, isbn13 = $11, asin = $12, slug = $13, version = version + 1
+				WHERE id = $14 AND version = $15
 				RETURNING version`
 
 	args := []interface{}{
@@ -81,14 +272,15 @@ func (e BookModel) Update(book *domain.Book) error {
 		book.Rating,
 		book.PeopleRated,
 		book.URL,
+		book.ISBN10,
+		book.ISBN13,
+		book.ASIN,
+		book.Slug,
 		book.ID,
 		book.Version,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	err := e.DB.QueryRowContext(ctx, query, args...).Scan(&book.Version)
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&book.Version)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -98,7 +290,24 @@ func (e BookModel) Update(book *domain.Book) error {
 		}
 	}
 
-	return nil
+	if book.Author != previousAuthor {
+		err = linkBookAuthors(ctx, tx, book.ID, book.Author)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = syncPrimaryEdition(ctx, tx, book)
+	if err != nil {
+		return err
+	}
+
+	err = recordPrices(ctx, tx, book.ID, PriceSourceEditor)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (e BookModel) Delete(id int64) error {
@@ -148,19 +357,23 @@ func (e BookModel) GetAll(mfilters filters.Filters, msearchOptions filters.BookS
 	}
 	if msearchOptions.Main_genre != "" {
 		where = append(where, fmt.Sprintf(
-			"(to_tsvector('simple', main_genre) @@ plainto_tsquery('simple', $%d) OR main_genre ILIKE $%d)", argPosition, argPosition+1))
+			"(to_tsvector('simple', books.main_genre) @@ plainto_tsquery('simple', $%[1]d) OR books.main_genre ILIKE $%[2]d"+
+				" OR EXISTS (SELECT 1 FROM book_genres bg WHERE bg.book_id = books.id AND (to_tsvector('simple', bg.main_genre) @@ plainto_tsquery('simple', $%[1]d) OR bg.main_genre ILIKE $%[2]d)))",
+			argPosition, argPosition+1))
 		args = append(args, msearchOptions.Main_genre, "%"+msearchOptions.Main_genre+"%")
 		argPosition += 2
 	}
 	if msearchOptions.Sub_genre != "" {
 		where = append(where, fmt.Sprintf(
-			"(to_tsvector('simple', sub_genre) @@ plainto_tsquery('simple', $%d) OR sub_genre ILIKE $%d)", argPosition, argPosition+1))
+			"(to_tsvector('simple', books.sub_genre) @@ plainto_tsquery('simple', $%[1]d) OR books.sub_genre ILIKE $%[2]d"+
+				" OR EXISTS (SELECT 1 FROM book_genres bg WHERE bg.book_id = books.id AND (to_tsvector('simple', bg.sub_genre) @@ plainto_tsquery('simple', $%[1]d) OR bg.sub_genre ILIKE $%[2]d)))",
+			argPosition, argPosition+1))
 		args = append(args, msearchOptions.Sub_genre, "%"+msearchOptions.Sub_genre+"%")
 		argPosition += 2
 	}
 	if msearchOptions.Type != "" {
 		where = append(where, fmt.Sprintf(
-			"(to_tsvector('simple', type) @@ plainto_tsquery('simple', $%d) OR type ILIKE $%d)", argPosition, argPosition+1))
+			"EXISTS (SELECT 1 FROM editions e WHERE e.book_id = books.id AND (to_tsvector('simple', e.type) @@ plainto_tsquery('simple', $%d) OR e.type ILIKE $%d))", argPosition, argPosition+1))
 		args = append(args, msearchOptions.Type, "%"+msearchOptions.Type+"%")
 		argPosition += 2
 	}
@@ -171,11 +384,16 @@ func (e BookModel) GetAll(mfilters filters.Filters, msearchOptions filters.BookS
 	}
 
 	query := fmt.Sprintf(`
-	SELECT count(*) OVER(), id, author, title, main_genre, sub_genre, type, price, rating, people_rated, url, version
+	WITH %s
+	SELECT count(*) OVER(), %s AS weighted_rating, %s
 	FROM books
+	LEFT JOIN genre_means ON genre_means.genre = books.main_genre
 	%s
-	ORDER BY %s %s, id ASC 
+	ORDER BY %s %s, books.id ASC
 	LIMIT $%d OFFSET $%d`,
+		genreMeansCTE,
+		weightedRatingExpr,
+		bookColumns,
 		whereClause,
 		mfilters.SortColumn(),
 		mfilters.SortDirection(),
@@ -197,20 +415,7 @@ func (e BookModel) GetAll(mfilters filters.Filters, msearchOptions filters.BookS
 
 	for rows.Next() {
 		var book domain.Book
-		err := rows.Scan(
-			&totalRecords,
-			&book.ID,
-			&book.Author,
-			&book.Title,
-			&book.MainGenre,
-			&book.SubGenre,
-			&book.Type,
-			&book.Price,
-			&book.Rating,
-			&book.PeopleRated,
-			&book.URL,
-			&book.Version,
-		)
+		err := scanBook(rows, &book, &totalRecords, &book.WeightedRating)
 		if err != nil {
 			return nil, filters.Metadata{}, err
 		}
@@ -226,11 +431,14 @@ func (e BookModel) GetAll(mfilters filters.Filters, msearchOptions filters.BookS
 	return books, metadata, nil
 }
 
+// GetByGenre returns the books listed under a main genre, as their own or as
+// one of their book_genres
 func (e BookModel) GetByGenre(genre string) ([]*domain.Book, error) {
 	query := `
-		SELECT id, author, title, main_genre, sub_genre, type, price, rating, people_rated, url, version
+		SELECT ` + bookColumns + `
 		FROM books
 		WHERE main_genre = $1
+			OR EXISTS (SELECT 1 FROM book_genres bg WHERE bg.book_id = books.id AND bg.main_genre = $1)
 	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -240,36 +448,55 @@ func (e BookModel) GetByGenre(genre string) ([]*domain.Book, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var books []*domain.Book
+	return scanBooks(rows)
+}
 
-	for rows.Next() {
-		var book domain.Book
-		err := rows.Scan(
-			&book.ID,
-			&book.Author,
-			&book.Title,
-			&book.MainGenre,
-			&book.SubGenre,
-			&book.Type,
-			&book.Price,
-			&book.Rating,
-			&book.PeopleRated,
-			&book.URL,
-			&book.Version,
-		)
-		if err != nil {
-			return nil, err
+// GetGenreMeanRating returns the vote-weighted mean rating of the books in a main genre
+func (e BookModel) GetGenreMeanRating(genre string) (float64, error) {
+	query := `WITH ` + genreMeansCTE + `
+		SELECT COALESCE(mean, 0)
+		FROM genre_means
+		WHERE genre = $1`
+
+	var mean float64
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := e.DB.QueryRowContext(ctx, query, genre).Scan(&mean)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, nil
+		default:
+			return 0, err
 		}
-		books = append(books, &book)
 	}
 
-	if err = rows.Err(); err != nil {
+	return mean, nil
+}
+
+// GetSimilar returns the most rated books from the same sub-genre, excluding the book itself
+func (e BookModel) GetSimilar(book *domain.Book, limit int) ([]*domain.Book, error) {
+	query := `
+		SELECT ` + bookColumns + `
+		FROM books
+		WHERE id <> $3 AND ((sub_genre = $1 AND main_genre = $2)
+			OR EXISTS (SELECT 1 FROM book_genres bg WHERE bg.book_id = books.id AND bg.sub_genre = $1 AND bg.main_genre = $2))
+		ORDER BY people_rated DESC NULLS LAST, rating DESC NULLS LAST, id ASC
+		LIMIT $4
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := e.DB.QueryContext(ctx, query, book.SubGenre, book.MainGenre, book.ID, limit)
+	if err != nil {
 		return nil, err
 	}
 
-	return books, nil
+	return scanBooks(rows)
 }
 
 func ValidateBook(v *validator.Validator, book *domain.Book) {
@@ -282,4 +509,5 @@ func ValidateBook(v *validator.Validator, book *domain.Book) {
 	v.Check(book.Rating != 0, "rating", "must be provided")
 	v.Check(book.PeopleRated != 0, "people_rated", "must be provided")
 	v.Check(book.URL != "", "url", "must be provided")
+	validateIdentifiers(v, book.ISBN10, book.ISBN13, book.ASIN)
 }
@@ -15,16 +15,19 @@ type CommentModel struct {
 	DB *sql.DB
 }
 
+const commentLikesExpr = `(SELECT count(*) FROM comment_likes l WHERE l.comment_id = comments.id)`
+
 func (m CommentModel) Insert(comment *domain.Comment) error {
 	query := `
-		INSERT INTO comments (book_id, user_id, content, created_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO comments (book_id, user_id, parent_id, content, created_at)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, version
 	`
 
 	args := []interface{}{
 		comment.BookID,
 		comment.UserID,
+		comment.ParentID,
 		comment.Content,
 		time.Now(),
 	}
@@ -41,7 +44,7 @@ func (m CommentModel) Get(id int64) (*domain.Comment, error) {
 	}
 
 	query := `
-		SELECT id, book_id, user_id, content, created_at, version
+		SELECT id, book_id, user_id, parent_id, content, ` + commentLikesExpr + `, created_at, version
 		FROM comments
 		WHERE id = $1
 	`
@@ -55,7 +58,9 @@ func (m CommentModel) Get(id int64) (*domain.Comment, error) {
 		&comment.ID,
 		&comment.BookID,
 		&comment.UserID,
+		&comment.ParentID,
 		&comment.Content,
+		&comment.Likes,
 		&comment.CreatedAt,
 		&comment.Version,
 	)
@@ -135,12 +140,12 @@ func (m CommentModel) Delete(id int64) error {
 
 func (m CommentModel) GetAllForBook(bookID int64, mfilters filters.Filters) ([]*domain.Comment, filters.Metadata, error) {
 	query := fmt.Sprintf(`
-		SELECT count(*) OVER(), id, book_id, user_id, content, created_at, version
+		SELECT count(*) OVER(), id, book_id, user_id, parent_id, content, %s, created_at, version
 		FROM comments
 		WHERE book_id = $1
 		ORDER BY %s %s, id ASC
 		LIMIT $2 OFFSET $3
-	`, mfilters.SortColumn(), mfilters.SortDirection())
+	`, commentLikesExpr, mfilters.SortColumn(), mfilters.SortDirection())
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -163,7 +168,9 @@ func (m CommentModel) GetAllForBook(bookID int64, mfilters filters.Filters) ([]*
 			&comment.ID,
 			&comment.BookID,
 			&comment.UserID,
+			&comment.ParentID,
 			&comment.Content,
+			&comment.Likes,
 			&comment.CreatedAt,
 			&comment.Version,
 		)
@@ -183,6 +190,54 @@ func (m CommentModel) GetAllForBook(bookID int64, mfilters filters.Filters) ([]*
 	return comments, metadata, nil
 }
 
+// Like records that the user likes the comment. It reports false when they
+// already did.
+func (m CommentModel) Like(commentID, userID int64) (bool, error) {
+	query := `
+		INSERT INTO comment_likes (comment_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (comment_id, user_id) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, commentID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (m CommentModel) Unlike(commentID, userID int64) error {
+	query := `
+		DELETE FROM comment_likes
+		WHERE comment_id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, commentID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
 func ValidateComment(v *validator.Validator, comment *domain.Comment) {
 	v.Check(comment.BookID > 0, "book_id", "must be provided")
 	v.Check(comment.UserID > 0, "user_id", "must be provided")
@@ -0,0 +1,86 @@
+package data
+
+import (
+	"book-service/internal/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// coverRecord is how a cover is kept in books.cover. Unlike the API
+// representation it includes the storage keys, so replaced covers can be
+// removed from storage.
+type coverRecord struct {
+	domain.Cover
+	Keys []string `json:"keys"`
+}
+
+func decodeCover(raw []byte) (*domain.Cover, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	var record coverRecord
+	err := json.Unmarshal(raw, &record)
+	if err != nil {
+		return nil, err
+	}
+
+	cover := record.Cover
+	cover.Keys = record.Keys
+	return &cover, nil
+}
+
+func encodeCover(cover *domain.Cover) (interface{}, error) {
+	if cover == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(coverRecord{Cover: *cover, Keys: cover.Keys})
+	if err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// SetCover replaces the book's cover, nil removes it. It returns the previous
+// cover so the caller can delete its files.
+func (e BookModel) SetCover(id int64, cover *domain.Cover) (*domain.Cover, error) {
+	raw, err := encodeCover(cover)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := e.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var old []byte
+	err = tx.QueryRowContext(ctx, `SELECT cover FROM books WHERE id = $1 FOR UPDATE`, id).Scan(&old)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE books SET cover = $1, version = version + 1 WHERE id = $2`, raw, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeCover(old)
+}
@@ -0,0 +1,337 @@
+package data
+
+import (
+	"book-service/internal/domain"
+	"book-service/internal/validator"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrPrimaryEdition = errors.New("the primary edition cannot be deleted, make another edition primary first")
+)
+
+type EditionModel struct {
+	DB *sql.DB
+}
+
+const editionColumns = `e.id, e.book_id, e.type, e.price, e.url, e.isbn10, e.isbn13, e.asin, e.is_primary, e.created_at, e.version`
+
+func scanEdition(scanner interface{ Scan(...interface{}) error }, edition *domain.Edition) error {
+	return scanner.Scan(
+		&edition.ID,
+		&edition.BookID,
+		&edition.Type,
+		&edition.Price,
+		&edition.URL,
+		&edition.ISBN10,
+		&edition.ISBN13,
+		&edition.ASIN,
+		&edition.IsPrimary,
+		&edition.CreatedAt,
+		&edition.Version,
+	)
+}
+
+// Insert adds an edition to a book. A primary edition replaces the book's
+// current primary edition.
+func (m EditionModel) Insert(edition *domain.Edition) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if edition.IsPrimary {
+		err = clearPrimaryEdition(ctx, tx, edition.BookID)
+		if err != nil {
+			return err
+		}
+	}
+
+	completeIdentifiers(&edition.ISBN10, &edition.ISBN13, &edition.ASIN, edition.URL)
+
+	query := `
+		INSERT INTO editions (book_id, type, price, url, isbn10, isbn13, asin, is_primary)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, version`
+
+	args := []interface{}{
+		edition.BookID,
+		edition.Type,
+		edition.Price,
+		edition.URL,
+		edition.ISBN10,
+		edition.ISBN13,
+		edition.ASIN,
+		edition.IsPrimary,
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&edition.ID, &edition.CreatedAt, &edition.Version)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrRecordNotFound
+		}
+		return err
+	}
+
+	if edition.IsPrimary {
+		err = mirrorPrimaryEdition(ctx, tx, edition)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = recordPrices(ctx, tx, edition.BookID, PriceSourceEditor)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m EditionModel) Get(id int64) (*domain.Edition, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `SELECT ` + editionColumns + ` FROM editions e WHERE e.id = $1`
+
+	var edition domain.Edition
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := scanEdition(m.DB.QueryRowContext(ctx, query, id), &edition)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &edition, nil
+}
+
+// Update saves the edition. Making an edition primary demotes the book's
+// previous primary edition.
+func (m EditionModel) Update(edition *domain.Edition) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if edition.IsPrimary {
+		_, err = tx.ExecContext(ctx, `UPDATE editions SET is_primary = false WHERE book_id = $1 AND is_primary AND id <> $2`, edition.BookID, edition.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	completeIdentifiers(&edition.ISBN10, &edition.ISBN13, &edition.ASIN, edition.URL)
+
+	query := `
+		UPDATE editions
+		SET type = $1, price = $2, url = $3, isbn10 = $4, isbn13 = $5, asin = $6, is_primary = $7, version = version + 1
+		WHERE id = $8 AND version = $9
+		RETURNING version`
+
+	args := []interface{}{
+		edition.Type,
+		edition.Price,
+		edition.URL,
+		edition.ISBN10,
+		edition.ISBN13,
+		edition.ASIN,
+		edition.IsPrimary,
+		edition.ID,
+		edition.Version,
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&edition.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	if edition.IsPrimary {
+		err = mirrorPrimaryEdition(ctx, tx, edition)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = recordPrices(ctx, tx, edition.BookID, PriceSourceEditor)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m EditionModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM editions
+		WHERE id = $1 AND NOT is_primary`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		var exists bool
+		err = m.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM editions WHERE id = $1)`, id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrPrimaryEdition
+		}
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetForBooks returns the editions of each of the books, primary edition first
+func (m EditionModel) GetForBooks(bookIDs []int64) (map[int64][]*domain.Edition, error) {
+	query := `SELECT ` + editionColumns + `
+		FROM editions e
+		WHERE e.book_id = ANY($1)
+		ORDER BY e.book_id, e.is_primary DESC, e.id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(bookIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	editions := make(map[int64][]*domain.Edition, len(bookIDs))
+
+	for rows.Next() {
+		var edition domain.Edition
+		err := scanEdition(rows, &edition)
+		if err != nil {
+			return nil, err
+		}
+		editions[edition.BookID] = append(editions[edition.BookID], &edition)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return editions, nil
+}
+
+// AttachEditions sets the Editions of every book
+func (m EditionModel) AttachEditions(books ...*domain.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+
+	editions, err := m.GetForBooks(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, book := range books {
+		book.Editions = editions[book.ID]
+		if book.Editions == nil {
+			book.Editions = []*domain.Edition{}
+		}
+	}
+
+	return nil
+}
+
+func clearPrimaryEdition(ctx context.Context, q queryer, bookID int64) error {
+	_, err := q.ExecContext(ctx, `UPDATE editions SET is_primary = false WHERE book_id = $1 AND is_primary`, bookID)
+	return err
+}
+
+// mirrorPrimaryEdition copies the primary edition's fields onto its book, so
+// filtering and sorting books by type or price keeps working.
+func mirrorPrimaryEdition(ctx context.Context, q queryer, edition *domain.Edition) error {
+	query := `
+		UPDATE books
+		SET type = $1, price = $2, url = $3, isbn10 = $4, isbn13 = $5, asin = $6, version = COALESCE(version, 0) + 1
+		WHERE id = $7`
+
+	args := []interface{}{edition.Type, edition.Price, edition.URL, edition.ISBN10, edition.ISBN13, edition.ASIN, edition.BookID}
+
+	_, err := q.ExecContext(ctx, query, args...)
+	return err
+}
+
+// syncPrimaryEdition is the other direction of mirrorPrimaryEdition: it saves a
+// book's type, price, URL and identifiers to its primary edition, creating it
+// if needed.
+func syncPrimaryEdition(ctx context.Context, q queryer, book *domain.Book) error {
+	args := []interface{}{book.ID, book.Type, book.Price, book.URL, book.ISBN10, book.ISBN13, book.ASIN}
+
+	query := `
+		UPDATE editions
+		SET type = $2, price = $3, url = $4, isbn10 = $5, isbn13 = $6, asin = $7, version = version + 1
+		WHERE book_id = $1 AND is_primary AND (type, price, url, isbn10, isbn13, asin) IS DISTINCT FROM ($2, $3, $4, $5, $6, $7)`
+
+	_, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO editions (book_id, type, price, url, isbn10, isbn13, asin, is_primary)
+		SELECT $1, $2, $3, $4, $5, $6, $7, true
+		WHERE NOT EXISTS (SELECT 1 FROM editions WHERE book_id = $1 AND is_primary)`
+
+	_, err = q.ExecContext(ctx, query, args...)
+	return err
+}
+
+func ValidateEdition(v *validator.Validator, edition *domain.Edition) {
+	v.Check(edition.Type != "", "type", "must be provided")
+	v.Check(len(edition.Type) <= 100, "type", "must not be more than 100 bytes long")
+	v.Check(edition.Price != "", "price", "must be provided")
+	v.Check(edition.URL != "", "url", "must be provided")
+	v.Check(len(edition.URL) <= 2000, "url", "must not be more than 2000 bytes long")
+	validateIdentifiers(v, edition.ISBN10, edition.ISBN13, edition.ASIN)
+}
@@ -31,7 +31,7 @@ func (m FavoriteBookModel) Insert(favoriteBook *domain.FavoriteBook) error {
 // GetAllForUser retrieves all favorite books for a specific user
 func (m FavoriteBookModel) GetAllForUser(userID int64) ([]*domain.FavoriteBook, error) {
 	query := `
-		SELECT id, user_id, book_name, created_at
+		SELECT id, user_id, book_name, created_at, price_alert_below, price_alert_triggered_at
 		FROM user_favorite_books
 		WHERE user_id = $1
 		ORDER BY created_at DESC`
@@ -54,6 +54,8 @@ func (m FavoriteBookModel) GetAllForUser(userID int64) ([]*domain.FavoriteBook,
 			&favoriteBook.UserID,
 			&favoriteBook.BookName,
 			&favoriteBook.CreatedAt,
+			&favoriteBook.PriceAlertBelow,
+			&favoriteBook.PriceAlertTriggeredAt,
 		)
 		if err != nil {
 			return nil, err
@@ -75,7 +77,7 @@ func (m FavoriteBookModel) Get(id int64) (*domain.FavoriteBook, error) {
 	}
 
 	query := `
-		SELECT id, user_id, book_name, created_at
+		SELECT id, user_id, book_name, created_at, price_alert_below, price_alert_triggered_at
 		FROM user_favorite_books
 		WHERE id = $1`
 
@@ -89,6 +91,8 @@ func (m FavoriteBookModel) Get(id int64) (*domain.FavoriteBook, error) {
 		&favoriteBook.UserID,
 		&favoriteBook.BookName,
 		&favoriteBook.CreatedAt,
+		&favoriteBook.PriceAlertBelow,
+		&favoriteBook.PriceAlertTriggeredAt,
 	)
 	if err != nil {
 		switch {
@@ -102,6 +106,23 @@ func (m FavoriteBookModel) Get(id int64) (*domain.FavoriteBook, error) {
 	return &favoriteBook, nil
 }
 
+// IsFavorite reports whether the user has a book with this title in their favorites
+func (m FavoriteBookModel) IsFavorite(userID int64, bookName string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM user_favorite_books
+			WHERE user_id = $1 AND book_name = $2
+		)`
+
+	var exists bool
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, userID, bookName).Scan(&exists)
+	return exists, err
+}
+
 // Delete removes a favorite book by its ID and user ID (for security)
 func (m FavoriteBookModel) Delete(id, userID int64) error {
 	if id < 1 {
@@ -131,6 +152,39 @@ func (m FavoriteBookModel) Delete(id, userID int64) error {
 	return nil
 }
 
+// SetPriceAlert sets or, with a nil threshold, clears the price-drop alert of
+// one of the user's favorites. Changing the threshold re-arms the alert.
+func (m FavoriteBookModel) SetPriceAlert(favoriteBook *domain.FavoriteBook) error {
+	query := `
+		UPDATE user_favorite_books
+		SET price_alert_below = $1, price_alert_triggered_at = NULL
+		WHERE id = $2 AND user_id = $3
+		RETURNING price_alert_triggered_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, favoriteBook.PriceAlertBelow, favoriteBook.ID, favoriteBook.UserID).
+		Scan(&favoriteBook.PriceAlertTriggeredAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ValidatePriceAlert(v *validator.Validator, below *float64) {
+	if below != nil {
+		v.Check(*below > 0, "below", "must be greater than zero")
+		v.Check(*below < 1e10, "below", "must be less than 10000000000")
+	}
+}
+
 // ValidateFavoriteBook validates the favorite book fields
 func ValidateFavoriteBook(v *validator.Validator, favoriteBook *domain.FavoriteBook) {
 	v.Check(favoriteBook.UserID != 0, "user_id", "must be provided")
@@ -0,0 +1,122 @@
+package data
+
+import (
+	"book-service/internal/domain"
+	"book-service/internal/filters"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrSelfFollow = errors.New("users cannot follow themselves")
+)
+
+type FollowModel struct {
+	DB *sql.DB
+}
+
+// Insert makes followerID follow followeeID. Following someone twice is not an error.
+func (m FollowModel) Insert(followerID, followeeID int64) error {
+	if followerID == followeeID {
+		return ErrSelfFollow
+	}
+
+	query := `
+		INSERT INTO follows (follower_id, followee_id)
+		VALUES ($1, $2)
+		ON CONFLICT (follower_id, followee_id) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, followerID, followeeID)
+	return err
+}
+
+func (m FollowModel) Delete(followerID, followeeID int64) error {
+	query := `
+		DELETE FROM follows
+		WHERE follower_id = $1 AND followee_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, followerID, followeeID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetFollowers lists the users following userID
+func (m FollowModel) GetFollowers(userID int64, mfilters filters.Filters) ([]*domain.Follow, filters.Metadata, error) {
+	query := `
+		SELECT count(*) OVER(), u.id, COALESCE(NULLIF(u.display_name, ''), u.name), f.created_at
+		FROM follows f
+		INNER JOIN users u ON u.id = f.follower_id
+		WHERE f.followee_id = $1
+		ORDER BY f.created_at DESC, u.id ASC
+		LIMIT $2 OFFSET $3`
+
+	return m.list(query, userID, mfilters)
+}
+
+// GetFollowing lists the users that userID follows
+func (m FollowModel) GetFollowing(userID int64, mfilters filters.Filters) ([]*domain.Follow, filters.Metadata, error) {
+	query := `
+		SELECT count(*) OVER(), u.id, COALESCE(NULLIF(u.display_name, ''), u.name), f.created_at
+		FROM follows f
+		INNER JOIN users u ON u.id = f.followee_id
+		WHERE f.follower_id = $1
+		ORDER BY f.created_at DESC, u.id ASC
+		LIMIT $2 OFFSET $3`
+
+	return m.list(query, userID, mfilters)
+}
+
+func (m FollowModel) list(query string, userID int64, mfilters filters.Filters) ([]*domain.Follow, filters.Metadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, mfilters.Limit(), mfilters.Offset())
+	if err != nil {
+		return nil, filters.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	follows := []*domain.Follow{}
+
+	for rows.Next() {
+		var follow domain.Follow
+		err := rows.Scan(
+			&totalRecords,
+			&follow.UserID,
+			&follow.Name,
+			&follow.FollowedAt,
+		)
+		if err != nil {
+			return nil, filters.Metadata{}, err
+		}
+		follows = append(follows, &follow)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, filters.Metadata{}, err
+	}
+
+	metadata := filters.CalculateMetadata(totalRecords, mfilters.Page, mfilters.PageSize)
+
+	return follows, metadata, nil
+}
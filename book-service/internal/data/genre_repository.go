@@ -16,15 +16,27 @@ type GenreModel struct {
 }
 
 func (e GenreModel) Insert(genre *domain.Genre) error {
-	query := `INSERT INTO genres (title, subgenre_count, url)
-				VALUES ($1, $2, $3) 
-				RETURNING id, version`
-
-	args := []interface{}{genre.Title, genre.SubgenreCount, genre.URL}
+	return retrySlugConflict(&genre.Slug, func() error {
+		return e.insert(genre)
+	})
+}
 
+func (e GenreModel) insert(genre *domain.Genre) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	var err error
+	genre.Slug, err = uniqueSlug(ctx, e.DB, SlugGenres, genre.Title, 0)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO genres (title, subgenre_count, url, slug)
+				VALUES ($1, $2, $3, $4)
+				RETURNING id, version`
+
+	args := []interface{}{genre.Title, genre.SubgenreCount, genre.URL, genre.Slug}
+
 	return e.DB.QueryRowContext(ctx, query, args...).Scan(&genre.ID, &genre.Version)
 }
 func (e GenreModel) Get(id int64) (*domain.Genre, error) {
@@ -32,7 +44,7 @@ func (e GenreModel) Get(id int64) (*domain.Genre, error) {
 		return nil, ErrRecordNotFound
 	}
 
-	query := `SELECT id, title, subgenre_count, url, version
+	query := `SELECT id, title, subgenre_count, url, slug, version
 				FROM genres
 				WHERE id = $1`
 	var genre domain.Genre
@@ -45,6 +57,7 @@ func (e GenreModel) Get(id int64) (*domain.Genre, error) {
 		&genre.Title,
 		&genre.SubgenreCount,
 		&genre.URL,
+		&genre.Slug,
 		&genre.Version,
 	)
 	if err != nil {
@@ -58,24 +71,44 @@ func (e GenreModel) Get(id int64) (*domain.Genre, error) {
 	return &genre, nil
 }
 
+// Update saves the genre. Changing the title gives the genre a new slug and
+// keeps the old one as a redirect.
 func (e GenreModel) Update(genre *domain.Genre) error {
+	return retrySlugConflict(&genre.Slug, func() error {
+		return e.update(genre)
+	})
+}
+
+func (e GenreModel) update(genre *domain.Genre) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := e.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	genre.Slug, err = renameSlug(ctx, tx, SlugGenres, genre.ID, genre.Slug, genre.Title)
+	if err != nil {
+		return err
+	}
+
 	query := `UPDATE genres
-				SET title = $1, subgenre_count = $2, url = $3, version = version + 1
-				WHERE id = $4 AND version = $5
+				SET title = $1, subgenre_count = $2, url = $3, slug = $4, version = version + 1
+				WHERE id = $5 AND version = $6
 				RETURNING version`
 
 	args := []interface{}{
 		genre.Title,
 		genre.SubgenreCount,
 		genre.URL,
+		genre.Slug,
 		genre.ID,
 		genre.Version,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	err := e.DB.QueryRowContext(ctx, query, args...).Scan(&genre.Version)
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&genre.Version)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -85,7 +118,7 @@ func (e GenreModel) Update(genre *domain.Genre) error {
 		}
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 func (e GenreModel) Delete(id int64) error {
@@ -117,7 +150,7 @@ func (e GenreModel) Delete(id int64) error {
 
 func (e GenreModel) GetAll(title string, mfilters filters.Filters) ([]*domain.Genre, filters.Metadata, error) {
 	query := fmt.Sprintf(`
-		SELECT count(*) OVER(), id, title, subgenre_count, url, version
+		SELECT count(*) OVER(), id, title, subgenre_count, url, slug, version
 		FROM genres
 		WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR $1 = '')
 		ORDER BY %s %s, id ASC
@@ -144,6 +177,7 @@ func (e GenreModel) GetAll(title string, mfilters filters.Filters) ([]*domain.Ge
 			&genre.Title,
 			&genre.SubgenreCount,
 			&genre.URL,
+			&genre.Slug,
 			&genre.Version,
 		)
 		if err != nil {
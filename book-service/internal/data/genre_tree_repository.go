@@ -0,0 +1,135 @@
+package data
+
+import (
+	"book-service/internal/domain"
+	"context"
+	"time"
+)
+
+type bookCountKey struct {
+	genre    string
+	subGenre string
+}
+
+// GetTree returns every genre with its sub-genres nested to any depth. Book
+// counts come from the books table rather than the stored book_count columns,
+// and a sub-genre's count includes the books of all its descendants.
+func (e GenreModel) GetTree() ([]*domain.GenreNode, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	counts := make(map[bookCountKey]int)
+	genreCounts := make(map[string]int)
+
+	rows, err := e.DB.QueryContext(ctx, `
+		SELECT COALESCE(main_genre, ''), COALESCE(sub_genre, ''), count(*)
+		FROM books
+		GROUP BY main_genre, sub_genre`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key bookCountKey
+		var count int
+		if err := rows.Scan(&key.genre, &key.subGenre, &count); err != nil {
+			return nil, err
+		}
+		counts[key] = count
+		genreCounts[key.genre] += count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = e.DB.QueryContext(ctx, `SELECT id, title, slug FROM genres ORDER BY title, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tree := []*domain.GenreNode{}
+	genres := make(map[int64]*domain.GenreNode)
+
+	for rows.Next() {
+		var node domain.GenreNode
+		if err := rows.Scan(&node.ID, &node.Title, &node.Slug); err != nil {
+			return nil, err
+		}
+		node.BookCount = genreCounts[node.Title]
+		node.Children = []*domain.CategoryNode{}
+		genres[node.ID] = &node
+		tree = append(tree, &node)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Sub-genres created before genre_id existed and never matched a genre are
+	// placed by their main_genre text instead.
+	rows, err = e.DB.QueryContext(ctx, `
+		SELECT s.id, s.title, s.slug, s.main_genre, s.parent_id,
+			COALESCE(s.genre_id, (SELECT g.id FROM genres g WHERE g.title = s.main_genre ORDER BY g.id LIMIT 1))
+		FROM subgenres s
+		ORDER BY s.title, s.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type placement struct {
+		node    *domain.CategoryNode
+		genreID *int64
+	}
+
+	nodes := make(map[int64]*domain.CategoryNode)
+	placements := []placement{}
+
+	for rows.Next() {
+		var node domain.CategoryNode
+		var mainGenre string
+		var genreID *int64
+		if err := rows.Scan(&node.ID, &node.Title, &node.Slug, &mainGenre, &node.ParentID, &genreID); err != nil {
+			return nil, err
+		}
+		node.BookCount = counts[bookCountKey{mainGenre, node.Title}]
+		node.Children = []*domain.CategoryNode{}
+		nodes[node.ID] = &node
+		placements = append(placements, placement{&node, genreID})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, p := range placements {
+		if p.node.ParentID != nil {
+			if parent, ok := nodes[*p.node.ParentID]; ok {
+				parent.Children = append(parent.Children, p.node)
+				continue
+			}
+		}
+		if p.genreID != nil {
+			if genre, ok := genres[*p.genreID]; ok {
+				genre.Children = append(genre.Children, p.node)
+			}
+		}
+	}
+
+	for _, genre := range tree {
+		for _, child := range genre.Children {
+			sumCategoryBooks(child)
+		}
+	}
+
+	return tree, nil
+}
+
+// sumCategoryBooks adds the book counts of every descendant into node and
+// returns the total.
+func sumCategoryBooks(node *domain.CategoryNode) int {
+	for _, child := range node.Children {
+		node.BookCount += sumCategoryBooks(child)
+	}
+	return node.BookCount
+}
@@ -0,0 +1,48 @@
+package data
+
+import (
+	"book-service/internal/isbn"
+	"book-service/internal/validator"
+	"regexp"
+)
+
+var asinRX = regexp.MustCompile(`^[A-Z0-9]{10}$`)
+
+// IsASIN reports whether s looks like an Amazon product identifier
+func IsASIN(s string) bool {
+	return asinRX.MatchString(s)
+}
+
+// completeIdentifiers fills in whichever of the ISBN-10, ISBN-13 and ASIN are
+// missing from the others and from the Amazon URL.
+func completeIdentifiers(isbn10, isbn13, asin *string, url string) {
+	*isbn10, *isbn13, *asin = isbn.Normalize(*isbn10), isbn.Normalize(*isbn13), isbn.Normalize(*asin)
+
+	if *asin == "" {
+		urlASIN, urlISBN10, urlISBN13 := isbn.FromAmazonURL(url)
+		*asin = urlASIN
+		if *isbn10 == "" && *isbn13 == "" {
+			*isbn10, *isbn13 = urlISBN10, urlISBN13
+		}
+	}
+
+	if *isbn13 == "" && isbn.Valid10(*isbn10) {
+		*isbn13, _ = isbn.To13(*isbn10)
+	}
+	if *isbn10 == "" && isbn.Valid13(*isbn13) {
+		*isbn10, _ = isbn.To10(*isbn13)
+	}
+}
+
+func validateIdentifiers(v *validator.Validator, isbn10, isbn13, asin string) {
+	isbn10, isbn13, asin = isbn.Normalize(isbn10), isbn.Normalize(isbn13), isbn.Normalize(asin)
+
+	v.Check(isbn10 == "" || isbn.Valid10(isbn10), "isbn_10", "must be a valid ISBN-10")
+	v.Check(isbn13 == "" || isbn.Valid13(isbn13), "isbn_13", "must be a valid ISBN-13")
+	v.Check(asin == "" || IsASIN(asin), "asin", "must be 10 letters or digits")
+
+	if isbn.Valid10(isbn10) && isbn.Valid13(isbn13) {
+		converted, _ := isbn.To13(isbn10)
+		v.Check(converted == isbn13, "isbn_13", "must be the same book as isbn_10")
+	}
+}
@@ -20,6 +20,18 @@ type Models struct {
 	Permissions  PermissionModel
 	Users        UserModel
 	FavoriteBook FavoriteBookModel
+	ReadingGoal  ReadingGoalModel
+	ReadingStats ReadingStatsModel
+	Follow       FollowModel
+	Activity     ActivityModel
+	Profiles     ProfileModel
+	BookList     BookListModel
+	Ranking      RankingModel
+	Slugs        SlugModel
+	Author       AuthorModel
+	Edition      EditionModel
+	Price        PriceModel
+	Notification NotificationModel
 }
 
 func NewModels(db *sql.DB) Models {
@@ -33,5 +45,17 @@ func NewModels(db *sql.DB) Models {
 		Permissions:  PermissionModel{DB: db},
 		Users:        UserModel{DB: db},
 		FavoriteBook: FavoriteBookModel{DB: db},
+		ReadingGoal:  ReadingGoalModel{DB: db},
+		ReadingStats: ReadingStatsModel{DB: db},
+		Follow:       FollowModel{DB: db},
+		Activity:     ActivityModel{DB: db},
+		Profiles:     ProfileModel{DB: db},
+		BookList:     BookListModel{DB: db},
+		Ranking:      RankingModel{DB: db},
+		Slugs:        SlugModel{DB: db},
+		Author:       AuthorModel{DB: db},
+		Edition:      EditionModel{DB: db},
+		Price:        PriceModel{DB: db},
+		Notification: NotificationModel{DB: db},
 	}
 }
@@ -0,0 +1,206 @@
+package data
+
+import (
+	"book-service/internal/domain"
+	"book-service/internal/filters"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type NotificationModel struct {
+	DB *sql.DB
+}
+
+const notificationColumns = `id, user_id, type, actor_id, book_id, subject_id, message, read_at, created_at`
+
+// actorName is the display name of the user joined as u, for notification messages
+const actorName = `COALESCE(NULLIF(u.display_name, ''), u.name, 'Someone')`
+
+func scanNotification(scanner interface{ Scan(...interface{}) error }, notification *domain.Notification, extra ...interface{}) error {
+	dest := append(extra,
+		&notification.ID,
+		&notification.UserID,
+		&notification.Type,
+		&notification.ActorID,
+		&notification.BookID,
+		&notification.SubjectID,
+		&notification.Message,
+		&notification.ReadAt,
+		&notification.CreatedAt,
+	)
+	return scanner.Scan(dest...)
+}
+
+// InsertForEvent stores a notification for every user the event concerns,
+// see domain.NotificationEvent, and returns them for live delivery.
+func (m NotificationModel) InsertForEvent(event *domain.NotificationEvent) ([]*domain.Notification, error) {
+	var query string
+	var args []interface{}
+
+	switch event.Type {
+	case domain.NotificationCommentReply, domain.NotificationCommentLike:
+		commentID, verb := event.TargetID, "replied to"
+		if event.Type == domain.NotificationCommentLike {
+			commentID, verb = event.SubjectID, "liked"
+		}
+
+		query = fmt.Sprintf(`
+			INSERT INTO notifications (user_id, type, actor_id, book_id, subject_id, message)
+			SELECT c.user_id, $1, u.id, c.book_id, $3, %s || ' %s your comment on ' || b.title
+			FROM comments c
+			INNER JOIN users recipient ON recipient.id = c.user_id
+			INNER JOIN books b ON b.id = c.book_id
+			LEFT JOIN users u ON u.id = $2
+			WHERE c.id = $4 AND c.user_id <> $2
+			RETURNING %s`, actorName, verb, notificationColumns)
+		args = []interface{}{event.Type, event.ActorID, event.SubjectID, commentID}
+
+	case domain.NotificationPriceDrop:
+		query = `
+			INSERT INTO notifications (user_id, type, book_id, subject_id, message)
+			SELECT id, $1, $3, $4, $5
+			FROM users
+			WHERE id = $2
+			RETURNING ` + notificationColumns
+		args = []interface{}{event.Type, event.UserID, event.BookID, event.SubjectID, event.Message}
+
+	case domain.NotificationNewBook:
+		// one notification per follower even when they follow several of the authors
+		query = fmt.Sprintf(`
+			INSERT INTO notifications (user_id, type, actor_id, book_id, subject_id, message)
+			SELECT DISTINCT ON (af.user_id) af.user_id, $1, u.id, b.id, b.id, 'New book by ' || a.name || ': ' || b.title
+			FROM book_authors ba
+			INNER JOIN authors a ON a.id = ba.author_id
+			INNER JOIN author_follows af ON af.author_id = ba.author_id
+			INNER JOIN books b ON b.id = ba.book_id
+			LEFT JOIN users u ON u.id = $2
+			WHERE ba.book_id = $3 AND af.user_id <> $2
+			ORDER BY af.user_id, ba.position
+			RETURNING %s`, notificationColumns)
+		args = []interface{}{event.Type, event.ActorID, event.BookID}
+
+	default:
+		return nil, fmt.Errorf("unknown notification type %q", event.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*domain.Notification{}
+
+	for rows.Next() {
+		var notification domain.Notification
+		err := scanNotification(rows, &notification)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, &notification)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// GetAllForUser returns the user's notifications, newest first
+func (m NotificationModel) GetAllForUser(userID int64, unreadOnly bool, mfilters filters.Filters) ([]*domain.Notification, filters.Metadata, error) {
+	query := `
+		SELECT count(*) OVER(), ` + notificationColumns + `
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, unreadOnly, mfilters.Limit(), mfilters.Offset())
+	if err != nil {
+		return nil, filters.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	notifications := []*domain.Notification{}
+
+	for rows.Next() {
+		var notification domain.Notification
+		err := scanNotification(rows, &notification, &totalRecords)
+		if err != nil {
+			return nil, filters.Metadata{}, err
+		}
+		notifications = append(notifications, &notification)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, filters.Metadata{}, err
+	}
+
+	metadata := filters.CalculateMetadata(totalRecords, mfilters.Page, mfilters.PageSize)
+
+	return notifications, metadata, nil
+}
+
+// MarkRead marks the given notifications of the user as read and returns how
+// many of them were unread
+func (m NotificationModel) MarkRead(userID int64, ids []int64) (int64, error) {
+	query := `
+		UPDATE notifications
+		SET read_at = NOW()
+		WHERE user_id = $1 AND id = ANY($2) AND read_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userID, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// MarkAllRead marks every unread notification of the user as read and returns how many there were
+func (m NotificationModel) MarkAllRead(userID int64) (int64, error) {
+	query := `
+		UPDATE notifications
+		SET read_at = NOW()
+		WHERE user_id = $1 AND read_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (m NotificationModel) UnreadCount(userID int64) (int, error) {
+	query := `
+		SELECT count(*)
+		FROM notifications
+		WHERE user_id = $1 AND read_at IS NULL`
+
+	var count int
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, userID).Scan(&count)
+	return count, err
+}
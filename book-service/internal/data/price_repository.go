@@ -0,0 +1,275 @@
+package data
+
+import (
+	"book-service/internal/domain"
+	"book-service/internal/isbn"
+	"book-service/internal/validator"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Price sources recorded in the history. Import runs name their own source.
+const (
+	PriceSourceImport = "import"
+	PriceSourceEditor = "editor"
+)
+
+// MaxPriceObservations bounds the size of one import request
+const MaxPriceObservations = 1000
+
+type PriceModel struct {
+	DB *sql.DB
+}
+
+// recordPrices adds a history point for every edition of the book whose price
+// differs from the last one recorded for it.
+func recordPrices(ctx context.Context, q queryer, bookID int64, source string) error {
+	query := `
+		INSERT INTO book_prices (book_id, edition_id, edition_type, price, source)
+		SELECT e.book_id, e.id, e.type, e.price, $2
+		FROM editions e
+		WHERE e.book_id = $1 AND e.price <> ''
+			AND e.price IS DISTINCT FROM (
+				SELECT p.price FROM book_prices p
+				WHERE p.edition_id = e.id
+				ORDER BY p.recorded_at DESC, p.id DESC
+				LIMIT 1
+			)`
+
+	_, err := q.ExecContext(ctx, query, bookID, source)
+	return err
+}
+
+// GetForBook returns the book's price history, oldest first, optionally
+// limited to one edition
+func (m PriceModel) GetForBook(bookID, editionID int64) ([]*domain.PricePoint, error) {
+	query := `
+		SELECT id, book_id, edition_id, edition_type, price, amount, source, recorded_at
+		FROM book_prices
+		WHERE book_id = $1 AND ($2 = 0 OR edition_id = $2)
+		ORDER BY recorded_at, id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, bookID, editionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []*domain.PricePoint{}
+
+	for rows.Next() {
+		var point domain.PricePoint
+		err := rows.Scan(
+			&point.ID,
+			&point.BookID,
+			&point.EditionID,
+			&point.EditionType,
+			&point.Price,
+			&point.Amount,
+			&point.Source,
+			&point.RecordedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, &point)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return points, nil
+}
+
+// Import applies the prices seen by an import run to the matching editions
+// and records every change under source.
+func (m PriceModel) Import(source string, observations []*domain.PriceObservation) (*domain.PriceImport, error) {
+	// a whole scrape is applied in one transaction, so allow more than the usual 3 seconds
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &domain.PriceImport{Unmatched: []int{}, BookIDs: []int64{}}
+	changed := map[int64]bool{}
+
+	for i, observation := range observations {
+		edition, err := matchEdition(ctx, tx, observation)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				result.Unmatched = append(result.Unmatched, i)
+				continue
+			}
+			return nil, err
+		}
+
+		if edition.Price == observation.Price {
+			result.Unchanged++
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE editions SET price = $1, version = version + 1 WHERE id = $2`, observation.Price, edition.ID)
+		if err != nil {
+			return nil, err
+		}
+		if edition.IsPrimary {
+			_, err = tx.ExecContext(ctx, `UPDATE books SET price = $1, version = COALESCE(version, 0) + 1 WHERE id = $2`, observation.Price, edition.BookID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result.Updated++
+		if !changed[edition.BookID] {
+			changed[edition.BookID] = true
+			result.BookIDs = append(result.BookIDs, edition.BookID)
+		}
+	}
+
+	for _, bookID := range result.BookIDs {
+		err = recordPrices(ctx, tx, bookID, source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// matchEdition finds the edition an observation is about, see domain.PriceObservation
+func matchEdition(ctx context.Context, q queryer, observation *domain.PriceObservation) (*domain.Edition, error) {
+	var edition domain.Edition
+
+	query := `SELECT ` + editionColumns + ` FROM editions e `
+	var args []interface{}
+
+	switch {
+	case observation.URL != "":
+		asin, _, _ := isbn.FromAmazonURL(observation.URL)
+		query += `WHERE e.url = $1 OR ($2 <> '' AND e.asin = $2) ORDER BY e.url = $1 DESC, e.id LIMIT 1`
+		args = []interface{}{observation.URL, asin}
+	case observation.Type != "":
+		query += `WHERE e.book_id = $1 AND lower(e.type) = lower($2) ORDER BY e.is_primary DESC, e.id LIMIT 1`
+		args = []interface{}{observation.BookID, observation.Type}
+	default:
+		query += `WHERE e.book_id = $1 AND e.is_primary`
+		args = []interface{}{observation.BookID}
+	}
+
+	err := scanEdition(q.QueryRowContext(ctx, query, args...), &edition)
+	if err != nil {
+		return nil, err
+	}
+	return &edition, nil
+}
+
+// TriggerAlerts fires the price-drop alerts on the book's favorites whose
+// threshold its cheapest current price has reached. Alerts fire once and
+// re-arm when the price rises above the threshold again.
+func (m PriceModel) TriggerAlerts(bookID int64) ([]*domain.PriceAlert, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// the latest point of every edition is its current price
+	query := `
+		SELECT min(amount) FROM (
+			SELECT DISTINCT ON (edition_id) amount
+			FROM book_prices
+			WHERE book_id = $1 AND edition_id IS NOT NULL
+			ORDER BY edition_id, recorded_at DESC, id DESC
+		) current`
+
+	var amount sql.NullFloat64
+	err = tx.QueryRowContext(ctx, query, bookID).Scan(&amount)
+	if err != nil {
+		return nil, err
+	}
+	if !amount.Valid {
+		return nil, nil
+	}
+
+	query = `
+		UPDATE user_favorite_books f
+		SET price_alert_triggered_at = NULL
+		FROM books b
+		WHERE b.id = $1 AND f.book_name = b.title
+			AND f.price_alert_triggered_at IS NOT NULL AND f.price_alert_below < $2`
+
+	_, err = tx.ExecContext(ctx, query, bookID, amount.Float64)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+		UPDATE user_favorite_books f
+		SET price_alert_triggered_at = NOW()
+		FROM books b
+		WHERE b.id = $1 AND f.book_name = b.title
+			AND f.price_alert_triggered_at IS NULL AND f.price_alert_below >= $2
+		RETURNING f.id, f.user_id, b.id, b.title, f.price_alert_below`
+
+	rows, err := tx.QueryContext(ctx, query, bookID, amount.Float64)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []*domain.PriceAlert{}
+
+	for rows.Next() {
+		alert := domain.PriceAlert{Amount: amount.Float64}
+		err := rows.Scan(&alert.FavoriteID, &alert.UserID, &alert.BookID, &alert.Title, &alert.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, &alert)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+func ValidatePriceImport(v *validator.Validator, source string, observations []*domain.PriceObservation) {
+	v.Check(source != "", "source", "must be provided")
+	v.Check(len(source) <= 100, "source", "must not be more than 100 bytes long")
+	v.Check(len(observations) > 0, "prices", "must contain at least one price")
+	v.Check(len(observations) <= MaxPriceObservations, "prices", "must not contain more than 1000 prices")
+
+	for _, observation := range observations {
+		if observation.Price == "" {
+			v.AddError("prices", "every price must be provided")
+		}
+		if observation.URL == "" && observation.BookID < 1 {
+			v.AddError("prices", "every price must have a url or a book_id")
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package data
+
+import (
+	"book-service/internal/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// PrivacySettings controls which parts of a profile other users can see.
+// The display name is always public.
+type PrivacySettings struct {
+	UserID       int64 `json:"-"`
+	ShowBio      bool  `json:"show_bio"`
+	ShowAvatar   bool  `json:"show_avatar"`
+	ShowJoinDate bool  `json:"show_join_date"`
+	ShowStats    bool  `json:"show_stats"`
+	ShowShelves  bool  `json:"show_shelves"`
+	Version      int   `json:"-"`
+}
+
+// DefaultPrivacySettings is used for users that never changed their settings
+func DefaultPrivacySettings(userID int64) *PrivacySettings {
+	return &PrivacySettings{
+		UserID:       userID,
+		ShowBio:      true,
+		ShowAvatar:   true,
+		ShowJoinDate: true,
+		ShowStats:    true,
+		ShowShelves:  true,
+	}
+}
+
+// PublicProfile is the redacted view of a User that is safe to show to other users.
+// Hidden fields are left nil and omitted from the JSON.
+type PublicProfile struct {
+	ID            int64                  `json:"id"`
+	DisplayName   string                 `json:"display_name"`
+	Bio           *string                `json:"bio,omitempty"`
+	AvatarURL     *string                `json:"avatar_url,omitempty"`
+	JoinedAt      *time.Time             `json:"joined_at,omitempty"`
+	RatingsCount  *int                   `json:"ratings_count,omitempty"`
+	CommentsCount *int                   `json:"comments_count,omitempty"`
+	Shelves       map[string]interface{} `json:"shelves,omitempty"`
+	Privacy       *PrivacySettings       `json:"privacy,omitempty"`
+}
+
+// NewPublicProfile builds the profile of user as seen by somebody else, or by the
+// owner when isOwner is set, in which case every field and the settings are included.
+func NewPublicProfile(user *User, privacy *PrivacySettings, counts ProfileCounts, favorites []*domain.FavoriteBook, isOwner bool) *PublicProfile {
+	profile := &PublicProfile{
+		ID:          user.ID,
+		DisplayName: user.DisplayName,
+	}
+	if profile.DisplayName == "" {
+		profile.DisplayName = user.Name
+	}
+
+	if isOwner || privacy.ShowBio {
+		profile.Bio = &user.Bio
+	}
+	if isOwner || privacy.ShowAvatar {
+		profile.AvatarURL = &user.AvatarURL
+	}
+	if isOwner || privacy.ShowJoinDate {
+		profile.JoinedAt = &user.CreatedAt
+	}
+	if isOwner || privacy.ShowStats {
+		profile.RatingsCount = &counts.Ratings
+		profile.CommentsCount = &counts.Comments
+	}
+	if isOwner || privacy.ShowShelves {
+		profile.Shelves = map[string]interface{}{"favorites": favorites}
+	}
+	if isOwner {
+		profile.Privacy = privacy
+	}
+
+	return profile
+}
+
+type ProfileCounts struct {
+	Ratings  int
+	Comments int
+}
+
+type ProfileModel struct {
+	DB *sql.DB
+}
+
+// GetPrivacy returns the privacy settings of a user, or the defaults if none were saved
+func (m ProfileModel) GetPrivacy(userID int64) (*PrivacySettings, error) {
+	query := `
+		SELECT user_id, show_bio, show_avatar, show_join_date, show_stats, show_shelves, version
+		FROM user_privacy_settings
+		WHERE user_id = $1`
+
+	var settings PrivacySettings
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, userID).Scan(
+		&settings.UserID,
+		&settings.ShowBio,
+		&settings.ShowAvatar,
+		&settings.ShowJoinDate,
+		&settings.ShowStats,
+		&settings.ShowShelves,
+		&settings.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return DefaultPrivacySettings(userID), nil
+		default:
+			return nil, err
+		}
+	}
+
+	return &settings, nil
+}
+
+// UpsertPrivacy saves the privacy settings of a user
+func (m ProfileModel) UpsertPrivacy(settings *PrivacySettings) error {
+	query := `
+		INSERT INTO user_privacy_settings (user_id, show_bio, show_avatar, show_join_date, show_stats, show_shelves)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id)
+		DO UPDATE SET show_bio = EXCLUDED.show_bio, show_avatar = EXCLUDED.show_avatar,
+			show_join_date = EXCLUDED.show_join_date, show_stats = EXCLUDED.show_stats,
+			show_shelves = EXCLUDED.show_shelves, version = user_privacy_settings.version + 1
+		RETURNING version`
+
+	args := []interface{}{
+		settings.UserID,
+		settings.ShowBio,
+		settings.ShowAvatar,
+		settings.ShowJoinDate,
+		settings.ShowStats,
+		settings.ShowShelves,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&settings.Version)
+}
+
+// GetCounts returns how many ratings and comments a user has written
+func (m ProfileModel) GetCounts(userID int64) (ProfileCounts, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM ratings WHERE user_id = $1),
+			(SELECT COUNT(*) FROM comments WHERE user_id = $1)`
+
+	var counts ProfileCounts
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, userID).Scan(&counts.Ratings, &counts.Comments)
+	if err != nil {
+		return ProfileCounts{}, err
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,177 @@
+package data
+
+import (
+	"book-service/internal/domain"
+	"context"
+	"database/sql"
+	"time"
+)
+
+const (
+	RankingGenreTop    = "genre_top"
+	RankingSubGenreTop = "sub_genre_top"
+)
+
+// TrendingWindows maps the supported trending windows to their length
+var TrendingWindows = map[string]string{
+	"day":   "1 day",
+	"week":  "7 days",
+	"month": "30 days",
+}
+
+// RankingSize is how many books are kept per ranking
+const RankingSize = 100
+
+type RankingModel struct {
+	DB *sql.DB
+}
+
+// Recompute rebuilds every ranking in a single transaction, so readers always
+// see a complete set.
+//
+// Genre and sub-genre rankings order books by their weighted rating. Trending
+// rankings score recent activity: ratings count 1, comments 1.5 and favorites 2.
+// A book's score is its activity in the window plus half of its growth over
+// the window before it. Favorites only store the book title, so each counts
+// for a single book of that title, matched as in readingActivityCTE.
+func (m RankingModel) Recompute() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM book_rankings`)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		WITH ` + genreMeansCTE + `,
+		ranked AS (
+			SELECT books.id, books.main_genre, books.sub_genre, ` + weightedRatingExpr + ` AS score
+			FROM books
+			LEFT JOIN genre_means ON genre_means.genre = books.main_genre
+		)
+		INSERT INTO book_rankings (kind, genre, sub_genre, rank, book_id, score)
+		SELECT $1, main_genre, '', rank, id, score
+		FROM (
+			SELECT id, main_genre, score, ROW_NUMBER() OVER (PARTITION BY main_genre ORDER BY score DESC, id ASC) AS rank
+			FROM ranked
+			WHERE main_genre IS NOT NULL
+		) t
+		WHERE rank <= $3
+		UNION ALL
+		SELECT $2, main_genre, sub_genre, rank, id, score
+		FROM (
+			SELECT id, main_genre, sub_genre, score, ROW_NUMBER() OVER (PARTITION BY main_genre, sub_genre ORDER BY score DESC, id ASC) AS rank
+			FROM ranked
+			WHERE main_genre IS NOT NULL AND sub_genre IS NOT NULL
+		) t
+		WHERE rank <= $3`
+
+	_, err = tx.ExecContext(ctx, query, RankingGenreTop, RankingSubGenreTop, RankingSize)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		WITH activity AS (
+			SELECT book_id, created_at, 1.0 AS weight FROM ratings
+			UNION ALL
+			SELECT book_id, created_at, 1.5 FROM comments
+			UNION ALL
+			SELECT b.id, f.created_at, 2.0
+			FROM user_favorite_books f
+			CROSS JOIN LATERAL (
+				SELECT id FROM books
+				WHERE title = f.book_name
+				ORDER BY id IN (
+					SELECT book_id FROM ratings WHERE user_id = f.user_id
+					UNION ALL
+					SELECT book_id FROM comments WHERE user_id = f.user_id
+				) DESC, id
+				LIMIT 1
+			) b
+		),
+		velocity AS (
+			SELECT book_id,
+				SUM(weight) FILTER (WHERE created_at > NOW() - $2::interval) AS current,
+				COALESCE(SUM(weight) FILTER (WHERE created_at <= NOW() - $2::interval), 0) AS previous
+			FROM activity
+			WHERE created_at > NOW() - 2 * $2::interval
+			GROUP BY book_id
+		)
+		INSERT INTO book_rankings (kind, rank, book_id, score)
+		SELECT $1, ROW_NUMBER() OVER (ORDER BY score DESC, book_id ASC), book_id, score
+		FROM (
+			SELECT book_id, current + 0.5 * GREATEST(current - previous, 0) AS score
+			FROM velocity
+			WHERE current > 0
+			ORDER BY score DESC, book_id ASC
+			LIMIT $3
+		) t`
+
+	for window, interval := range TrendingWindows {
+		_, err = tx.ExecContext(ctx, query, "trending_"+window, interval, RankingSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetTrending returns the trending books for one of TrendingWindows
+func (m RankingModel) GetTrending(window string, limit int) ([]*domain.RankedBook, error) {
+	return m.get("trending_"+window, "", "", limit)
+}
+
+func (m RankingModel) GetGenreTop(genre string, limit int) ([]*domain.RankedBook, error) {
+	return m.get(RankingGenreTop, genre, "", limit)
+}
+
+func (m RankingModel) GetSubGenreTop(genre, subGenre string, limit int) ([]*domain.RankedBook, error) {
+	return m.get(RankingSubGenreTop, genre, subGenre, limit)
+}
+
+func (m RankingModel) get(kind, genre, subGenre string, limit int) ([]*domain.RankedBook, error) {
+	query := `
+		SELECT r.rank, r.score, r.computed_at, ` + bookColumns + `
+		FROM book_rankings r
+		INNER JOIN books ON books.id = r.book_id
+		WHERE r.kind = $1 AND r.genre = $2 AND r.sub_genre = $3
+		ORDER BY r.rank
+		LIMIT $4`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, kind, genre, subGenre, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ranked := []*domain.RankedBook{}
+
+	for rows.Next() {
+		var entry domain.RankedBook
+		var book domain.Book
+		err := scanBook(rows, &book, &entry.Rank, &entry.Score, &entry.ComputedAt)
+		if err != nil {
+			return nil, err
+		}
+		entry.Book = &book
+		ranked = append(ranked, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ranked, nil
+}
@@ -13,6 +13,19 @@ type RatingModel struct {
 	DB *sql.DB
 }
 
+// RatingPriorWeight is how many votes the genre mean counts for when adjusting
+// a book's rating, so books with few votes stay close to their genre mean.
+const RatingPriorWeight = 50
+
+// BayesianRating shrinks an average of count votes towards priorMean.
+// With no votes it is priorMean, and it approaches mean as votes grow.
+func BayesianRating(mean float64, count int64, priorMean float64) float64 {
+	if priorMean == 0 {
+		priorMean = mean
+	}
+	return (RatingPriorWeight*priorMean + mean*float64(count)) / (RatingPriorWeight + float64(count))
+}
+
 func (m RatingModel) Insert(rating *domain.Rating) error {
 	// First check if the user has already rated this book
 	query := `
@@ -256,6 +269,41 @@ func (m RatingModel) GetAverageRating(bookID int64) (float64, int, error) {
 	return averageScore, count, nil
 }
 
+// GetDistribution counts the ratings of a book for every score from 1 to 5
+func (m RatingModel) GetDistribution(bookID int64) (map[int]int, error) {
+	query := `
+		SELECT score, COUNT(*)
+		FROM ratings
+		WHERE book_id = $1
+		GROUP BY score`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	distribution := map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+
+	for rows.Next() {
+		var score, count int
+		err := rows.Scan(&score, &count)
+		if err != nil {
+			return nil, err
+		}
+		distribution[score] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return distribution, nil
+}
+
 func ValidateRating(v *validator.Validator, rating *domain.Rating) {
 	v.Check(rating.BookID > 0, "book_id", "must be provided")
 	v.Check(rating.UserID > 0, "user_id", "must be provided")
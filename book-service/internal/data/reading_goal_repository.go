@@ -0,0 +1,112 @@
+package data
+
+import (
+	"book-service/internal/domain"
+	"book-service/internal/validator"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type ReadingGoalModel struct {
+	DB *sql.DB
+}
+
+// Upsert creates the goal for the user and year or replaces the target of an existing one
+func (m ReadingGoalModel) Upsert(goal *domain.ReadingGoal) error {
+	query := `
+		INSERT INTO reading_goals (user_id, year, target)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, year)
+		DO UPDATE SET target = EXCLUDED.target, version = reading_goals.version + 1
+		RETURNING id, created_at, version`
+
+	args := []interface{}{goal.UserID, goal.Year, goal.Target}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&goal.ID, &goal.CreatedAt, &goal.Version)
+}
+
+// GetForYear retrieves the goal a user set for a specific year
+func (m ReadingGoalModel) GetForYear(userID int64, year int) (*domain.ReadingGoal, error) {
+	query := `
+		SELECT id, user_id, year, target, created_at, version
+		FROM reading_goals
+		WHERE user_id = $1 AND year = $2`
+
+	var goal domain.ReadingGoal
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, userID, year).Scan(
+		&goal.ID,
+		&goal.UserID,
+		&goal.Year,
+		&goal.Target,
+		&goal.CreatedAt,
+		&goal.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &goal, nil
+}
+
+// GetAllForUser retrieves every goal a user has set, newest year first
+func (m ReadingGoalModel) GetAllForUser(userID int64) ([]*domain.ReadingGoal, error) {
+	query := `
+		SELECT id, user_id, year, target, created_at, version
+		FROM reading_goals
+		WHERE user_id = $1
+		ORDER BY year DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	goals := []*domain.ReadingGoal{}
+
+	for rows.Next() {
+		var goal domain.ReadingGoal
+		err := rows.Scan(
+			&goal.ID,
+			&goal.UserID,
+			&goal.Year,
+			&goal.Target,
+			&goal.CreatedAt,
+			&goal.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, &goal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return goals, nil
+}
+
+func ValidateReadingGoal(v *validator.Validator, goal *domain.ReadingGoal) {
+	v.Check(goal.UserID > 0, "user_id", "must be provided")
+	v.Check(goal.Year >= 1900 && goal.Year <= 3000, "year", "must be a valid year")
+	v.Check(goal.Target > 0, "target", "must be greater than zero")
+	v.Check(goal.Target <= 10_000, "target", "must not be more than 10000")
+}
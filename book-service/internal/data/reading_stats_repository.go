@@ -0,0 +1,194 @@
+package data
+
+import (
+	"book-service/internal/domain"
+	"context"
+	"database/sql"
+	"math"
+	"time"
+)
+
+// readingActivityCTE collects every book a user ($1) has interacted with,
+// together with the earliest time they did so. Favorites only store the book
+// title, so each is matched back to a single book of that title: one the user
+// rated or commented on if any, otherwise the lowest id. Books sharing a
+// title are thus not counted once each.
+const readingActivityCTE = `
+	WITH rated AS (
+		SELECT book_id, created_at FROM ratings WHERE user_id = $1
+		UNION ALL
+		SELECT book_id, created_at FROM comments WHERE user_id = $1
+	),
+	finished AS (
+		SELECT book_id, MIN(created_at) AS finished_at
+		FROM (
+			SELECT book_id, created_at FROM rated
+			UNION ALL
+			SELECT b.id, f.created_at
+			FROM user_favorite_books f
+			CROSS JOIN LATERAL (
+				SELECT id FROM books
+				WHERE title = f.book_name
+				ORDER BY id IN (SELECT book_id FROM rated) DESC, id
+				LIMIT 1
+			) b
+			WHERE f.user_id = $1
+		) activity
+		GROUP BY book_id
+	)`
+
+type ReadingStatsModel struct {
+	DB *sql.DB
+}
+
+// GetForUser builds the reading statistics of a user for the given year
+func (m ReadingStatsModel) GetForUser(userID int64, year int) (*domain.ReadingStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats := &domain.ReadingStats{
+		Year:             year,
+		FinishedPerMonth: make([]domain.PeriodCount, 12),
+	}
+	for i := range stats.FinishedPerMonth {
+		stats.FinishedPerMonth[i].Period = i + 1
+	}
+
+	query := readingActivityCTE + `
+		SELECT EXTRACT(MONTH FROM finished_at)::int, COUNT(*)
+		FROM finished
+		WHERE EXTRACT(YEAR FROM finished_at) = $2
+		GROUP BY 1`
+
+	monthly, err := m.queryPeriodCounts(ctx, query, userID, year)
+	if err != nil {
+		return nil, err
+	}
+	for _, month := range monthly {
+		stats.FinishedPerMonth[month.Period-1].Count = month.Count
+		stats.BooksFinished += month.Count
+	}
+
+	query = readingActivityCTE + `
+		SELECT EXTRACT(YEAR FROM finished_at)::int, COUNT(*)
+		FROM finished
+		GROUP BY 1
+		ORDER BY 1`
+
+	stats.FinishedPerYear, err = m.queryPeriodCounts(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.GenreDistribution, err = m.queryDistribution(ctx, "main_genre", userID, year)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.SubGenreDistribution, err = m.queryDistribution(ctx, "sub_genre", userID, year)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT COALESCE(AVG(score), 0), COUNT(*)
+		FROM ratings
+		WHERE user_id = $1 AND EXTRACT(YEAR FROM created_at) = $2`
+
+	err = m.DB.QueryRowContext(ctx, query, userID, year).Scan(&stats.AverageRatingGiven, &stats.RatingsGiven)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (m ReadingStatsModel) queryPeriodCounts(ctx context.Context, query string, args ...interface{}) ([]domain.PeriodCount, error) {
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []domain.PeriodCount{}
+
+	for rows.Next() {
+		var count domain.PeriodCount
+		err := rows.Scan(&count.Period, &count.Count)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// queryDistribution counts the books finished in a year grouped by a books column.
+// column is never user supplied.
+func (m ReadingStatsModel) queryDistribution(ctx context.Context, column string, userID int64, year int) ([]domain.CategoryCount, error) {
+	query := readingActivityCTE + `
+		SELECT COALESCE(b.` + column + `, ''), COUNT(*)
+		FROM finished f
+		INNER JOIN books b ON b.id = f.book_id
+		WHERE EXTRACT(YEAR FROM f.finished_at) = $2
+		GROUP BY 1
+		ORDER BY 2 DESC, 1 ASC`
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []domain.CategoryCount{}
+
+	for rows.Next() {
+		var count domain.CategoryCount
+		err := rows.Scan(&count.Name, &count.Count)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// CalculateGoalProgress compares the books finished so far with the goal target.
+// A user is on track when they have finished at least the share of the target
+// that corresponds to the elapsed part of the year.
+func CalculateGoalProgress(goal *domain.ReadingGoal, finished int, now time.Time) *domain.GoalProgress {
+	progress := &domain.GoalProgress{
+		Target:    goal.Target,
+		Finished:  finished,
+		Remaining: goal.Target - finished,
+		Percent:   math.Round(float64(finished)/float64(goal.Target)*1000) / 10,
+	}
+	if progress.Remaining < 0 {
+		progress.Remaining = 0
+	}
+
+	start := time.Date(goal.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+
+	switch {
+	case now.Before(start):
+		progress.OnTrack = true
+	case now.After(end):
+		progress.OnTrack = finished >= goal.Target
+	default:
+		elapsed := now.Sub(start).Hours() / end.Sub(start).Hours()
+		progress.OnTrack = float64(finished) >= elapsed*float64(goal.Target)
+	}
+
+	return progress
+}
@@ -0,0 +1,178 @@
+package data
+
+import (
+	"book-service/internal/slug"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Tables that carry a slug column. They double as the entity names in slug_redirects.
+const (
+	SlugBooks     = "books"
+	SlugGenres    = "genres"
+	SlugSubGenres = "subgenres"
+	SlugAuthors   = "authors"
+)
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+type SlugModel struct {
+	DB *sql.DB
+}
+
+// Resolve finds the row of table that slug belongs to. A slug the row used
+// before it was renamed still resolves, in which case current differs from
+// the slug asked for and callers should redirect to it.
+func (m SlugModel) Resolve(table, s string) (id int64, current string, err error) {
+	query := fmt.Sprintf(`
+		SELECT id, slug FROM (
+			SELECT t.id, t.slug, 0 AS priority FROM %[1]s t WHERE t.slug = $1
+			UNION ALL
+			SELECT t.id, t.slug, 1 FROM slug_redirects r
+			INNER JOIN %[1]s t ON t.id = r.target_id
+			WHERE r.entity = $2 AND r.slug = $1
+		) found
+		ORDER BY priority
+		LIMIT 1`, table)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err = m.DB.QueryRowContext(ctx, query, s, table).Scan(&id, &current)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, "", ErrRecordNotFound
+		default:
+			return 0, "", err
+		}
+	}
+
+	return id, current, nil
+}
+
+// uniqueSlug returns the slug of title, with the smallest numeric suffix that
+// keeps it unique among the other rows of table.
+func uniqueSlug(ctx context.Context, q queryer, table, title string, id int64) (string, error) {
+	base := slug.Make(title)
+	if base == "" {
+		base = "untitled"
+	}
+
+	query := fmt.Sprintf(`SELECT slug FROM %s WHERE (slug = $1 OR slug LIKE $1 || '-%%') AND id <> $2`, table)
+
+	rows, err := q.QueryContext(ctx, query, base, id)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	taken := make(map[string]bool)
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return "", err
+		}
+		taken[s] = true
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	candidate := base
+	for n := 2; taken[candidate]; n++ {
+		candidate = base + "-" + strconv.Itoa(n)
+	}
+
+	return candidate, nil
+}
+
+// slugAttempts is how many times a save is tried when concurrent saves race
+// for the same slug
+const slugAttempts = 3
+
+// retrySlugConflict runs save again when it fails on the unique index of a
+// slug column, because a concurrent save took the slug it picked in between.
+// field is reset before every attempt so the slug is picked afresh.
+func retrySlugConflict(field *string, save func() error) error {
+	original := *field
+
+	var err error
+	for attempt := 0; attempt < slugAttempts; attempt++ {
+		*field = original
+		err = save()
+		if !isSlugConflict(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func isSlugConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && strings.Contains(pqErr.Constraint, "slug")
+}
+
+// slugFits reports whether current is still a valid slug for title, so slugs
+// only change when the title does.
+func slugFits(current, title string) bool {
+	base := slug.Make(title)
+	if base == "" {
+		base = "untitled"
+	}
+	if current == base {
+		return true
+	}
+	suffix, found := strings.CutPrefix(current, base+"-")
+	if !found {
+		return false
+	}
+	_, err := strconv.Atoi(suffix)
+	return err == nil
+}
+
+// renameSlug gives the row a fresh slug when its title no longer matches the
+// current one and keeps the old slug as a redirect. It returns the slug the
+// row should be saved with.
+func renameSlug(ctx context.Context, q queryer, table string, id int64, current, title string) (string, error) {
+	if current != "" && slugFits(current, title) {
+		return current, nil
+	}
+
+	next, err := uniqueSlug(ctx, q, table, title, id)
+	if err != nil {
+		return "", err
+	}
+
+	if current != "" {
+		query := `
+			INSERT INTO slug_redirects (entity, slug, target_id)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (entity, slug) DO UPDATE SET target_id = EXCLUDED.target_id, created_at = NOW()`
+
+		_, err = q.ExecContext(ctx, query, table, current, id)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// a live slug always wins over an old one
+	_, err = q.ExecContext(ctx, `DELETE FROM slug_redirects WHERE entity = $1 AND slug = $2`, table, next)
+	if err != nil {
+		return "", err
+	}
+
+	return next, nil
+}
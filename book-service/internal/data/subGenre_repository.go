@@ -12,20 +12,36 @@ import (
 	"time"
 )
 
+var (
+	ErrInvalidParent = errors.New("parent must not be the sub-genre itself or one of its descendants")
+)
+
 type SubGenreModel struct {
 	DB *sql.DB
 }
 
 func (e SubGenreModel) Insert(sub_genre *domain.SubGenre) error {
-	query := `INSERT INTO subgenres ( title, main_genre, book_count, url)
-				VALUES ($1, $2, $3, $4)
-				RETURNING id, version`
-
-	args := []interface{}{sub_genre.Title, sub_genre.MainGenre, sub_genre.BookCount, sub_genre.URL}
+	return retrySlugConflict(&sub_genre.Slug, func() error {
+		return e.insert(sub_genre)
+	})
+}
 
+func (e SubGenreModel) insert(sub_genre *domain.SubGenre) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	var err error
+	sub_genre.Slug, err = uniqueSlug(ctx, e.DB, SlugSubGenres, sub_genre.Title, 0)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO subgenres ( title, main_genre, book_count, url, slug, parent_id, genre_id)
+				VALUES ($1, $2, $3, $4, $5, $6, (SELECT id FROM genres WHERE title = $2 ORDER BY id LIMIT 1))
+				RETURNING id, version`
+
+	args := []interface{}{sub_genre.Title, sub_genre.MainGenre, sub_genre.BookCount, sub_genre.URL, sub_genre.Slug, sub_genre.ParentID}
+
 	return e.DB.QueryRowContext(ctx, query, args...).Scan(&sub_genre.ID, &sub_genre.Version)
 }
 
@@ -34,7 +50,7 @@ func (m SubGenreModel) Get(id int64) (*domain.SubGenre, error) {
 		return nil, ErrRecordNotFound
 	}
 
-	query := `SELECT id, title, main_genre, book_count, url, 1 FROM subgenres WHERE id = $1`
+	query := `SELECT id, title, main_genre, book_count, url, slug, parent_id, 1 FROM subgenres WHERE id = $1`
 
 	var subGenre domain.SubGenre
 
@@ -47,6 +63,39 @@ func (m SubGenreModel) Get(id int64) (*domain.SubGenre, error) {
 		&subGenre.MainGenre,
 		&subGenre.BookCount,
 		&subGenre.URL,
+		&subGenre.Slug,
+		&subGenre.ParentID,
+		&subGenre.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &subGenre, nil
+}
+
+// GetByTitle finds the sub-genre a book belongs to from the book's sub_genre and main_genre text
+func (m SubGenreModel) GetByTitle(title, mainGenre string) (*domain.SubGenre, error) {
+	query := `SELECT id, title, main_genre, book_count, url, slug, parent_id, 1 FROM subgenres WHERE title = $1 AND main_genre = $2 ORDER BY id LIMIT 1`
+
+	var subGenre domain.SubGenre
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, title, mainGenre).Scan(
+		&subGenre.ID,
+		&subGenre.Title,
+		&subGenre.MainGenre,
+		&subGenre.BookCount,
+		&subGenre.URL,
+		&subGenre.Slug,
+		&subGenre.ParentID,
 		&subGenre.Version,
 	)
 	if err != nil {
@@ -61,25 +110,47 @@ func (m SubGenreModel) Get(id int64) (*domain.SubGenre, error) {
 	return &subGenre, nil
 }
 
+// Update saves the sub-genre. Changing the title gives the sub-genre a new slug
+// and keeps the old one as a redirect.
 func (m SubGenreModel) Update(subGenre *domain.SubGenre) error {
+	return retrySlugConflict(&subGenre.Slug, func() error {
+		return m.update(subGenre)
+	})
+}
+
+func (m SubGenreModel) update(subGenre *domain.SubGenre) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	subGenre.Slug, err = renameSlug(ctx, tx, SlugSubGenres, subGenre.ID, subGenre.Slug, subGenre.Title)
+	if err != nil {
+		return err
+	}
+
 	query := `UPDATE subgenres
-				SET title = $1, main_genre = $2, book_count = $3, url = $4
-				WHERE id = $5 AND version = $6
-				RETURNING 1`
+				SET title = $1, main_genre = $2, book_count = $3, url = $4, slug = $5,
+					genre_id = (SELECT id FROM genres WHERE title = $2 ORDER BY id LIMIT 1),
+					version = version + 1
+				WHERE id = $6 AND version = $7
+				RETURNING version`
 
 	args := []interface{}{
 		subGenre.Title,
 		subGenre.MainGenre,
 		subGenre.BookCount,
 		subGenre.URL,
+		subGenre.Slug,
 		subGenre.ID,
 		subGenre.Version,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&subGenre.Version)
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&subGenre.Version)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -89,7 +160,141 @@ func (m SubGenreModel) Update(subGenre *domain.SubGenre) error {
 		}
 	}
 
-	return nil
+	return tx.Commit()
+}
+
+// Move places a sub-genre, together with all of its descendants, under a new
+// parent. Exactly one of genreID or parentID should be set: a genre makes the
+// sub-genre top level, a parent nests it below another sub-genre. The books of
+// every moved sub-genre follow it to its new main genre. The sub-genre must
+// still be at version, otherwise ErrEditConflict is returned; the versions of
+// the moved sub-genre and of the descendants whose genre changed go up.
+func (m SubGenreModel) Move(id int64, version int32, genreID, parentID *int64) (*domain.SubGenre, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locking the row makes a concurrent move wait for this one and then
+	// find the version changed
+	err = tx.QueryRowContext(ctx, `SELECT 1 FROM subgenres WHERE id = $1 AND version = $2 FOR UPDATE`, id, version).Scan(new(int))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+
+	var mainGenre string
+	var newGenreID int64
+
+	if parentID != nil {
+		query := `
+			WITH RECURSIVE subtree AS (
+				SELECT id FROM subgenres WHERE id = $1
+				UNION ALL
+				SELECT s.id FROM subgenres s INNER JOIN subtree t ON s.parent_id = t.id
+			)
+			SELECT p.main_genre, COALESCE(p.genre_id, (SELECT g.id FROM genres g WHERE g.title = p.main_genre ORDER BY g.id LIMIT 1), 0),
+				EXISTS (SELECT 1 FROM subtree WHERE id = p.id)
+			FROM subgenres p
+			WHERE p.id = $2`
+
+		var inSubtree bool
+		err = tx.QueryRowContext(ctx, query, id, *parentID).Scan(&mainGenre, &newGenreID, &inSubtree)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return nil, ErrRecordNotFound
+			default:
+				return nil, err
+			}
+		}
+		if inSubtree {
+			return nil, ErrInvalidParent
+		}
+	} else {
+		err = tx.QueryRowContext(ctx, `SELECT id, title FROM genres WHERE id = $1`, *genreID).Scan(&newGenreID, &mainGenre)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return nil, ErrRecordNotFound
+			default:
+				return nil, err
+			}
+		}
+	}
+
+	subtree := `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM subgenres WHERE id = $1
+			UNION ALL
+			SELECT s.id FROM subgenres s INNER JOIN subtree t ON s.parent_id = t.id
+		)`
+
+	query := subtree + `
+		UPDATE books b
+		SET main_genre = $2, version = COALESCE(b.version, 0) + 1
+		FROM subgenres s
+		WHERE s.id IN (SELECT id FROM subtree) AND b.main_genre = s.main_genre AND b.sub_genre = s.title`
+
+	_, err = tx.ExecContext(ctx, query, id, mainGenre)
+	if err != nil {
+		return nil, err
+	}
+
+	query = subtree + `
+		UPDATE subgenres
+		SET main_genre = $2, genre_id = NULLIF($3::bigint, 0),
+			version = CASE
+				WHEN id <> $1 AND (main_genre, genre_id) IS DISTINCT FROM ($2, NULLIF($3::bigint, 0)) THEN version + 1
+				ELSE version
+			END
+		WHERE id IN (SELECT id FROM subtree)`
+
+	_, err = tx.ExecContext(ctx, query, id, mainGenre, newGenreID)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+		UPDATE subgenres
+		SET parent_id = $2, version = version + 1
+		WHERE id = $1
+		RETURNING id, title, main_genre, book_count, url, slug, parent_id, version`
+
+	var subGenre domain.SubGenre
+
+	err = tx.QueryRowContext(ctx, query, id, parentID).Scan(
+		&subGenre.ID,
+		&subGenre.Title,
+		&subGenre.MainGenre,
+		&subGenre.BookCount,
+		&subGenre.URL,
+		&subGenre.Slug,
+		&subGenre.ParentID,
+		&subGenre.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &subGenre, nil
 }
 
 func (m SubGenreModel) Delete(id int64) error {
@@ -120,7 +325,7 @@ func (m SubGenreModel) Delete(id int64) error {
 
 func (m SubGenreModel) GetAll(title string, mfilters filters.Filters) ([]*domain.SubGenre, filters.Metadata, error) {
 	query := fmt.Sprintf(`
-		SELECT count(*) OVER(), id, title, main_genre, book_count, url, 1
+		SELECT count(*) OVER(), id, title, main_genre, book_count, url, slug, parent_id, 1
 		FROM subgenres
 		WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR $1 = '')
 		ORDER BY %s %s, id ASC
@@ -147,6 +352,8 @@ func (m SubGenreModel) GetAll(title string, mfilters filters.Filters) ([]*domain
 			&sg.MainGenre,
 			&sg.BookCount,
 			&sg.URL,
+			&sg.Slug,
+			&sg.ParentID,
 			&sg.Version,
 		)
 
@@ -165,7 +372,7 @@ func (m SubGenreModel) GetAll(title string, mfilters filters.Filters) ([]*domain
 }
 
 func (m SubGenreModel) GetByGenre(genre string) ([]*domain.SubGenre, error) {
-	query := `SELECT id, title, main_genre, book_count, url, 1 FROM subgenres WHERE main_genre = $1`
+	query := `SELECT id, title, main_genre, book_count, url, slug, parent_id, 1 FROM subgenres WHERE main_genre = $1`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -186,6 +393,8 @@ func (m SubGenreModel) GetByGenre(genre string) ([]*domain.SubGenre, error) {
 			&sg.MainGenre,
 			&sg.BookCount,
 			&sg.URL,
+			&sg.Slug,
+			&sg.ParentID,
 			&sg.Version,
 		)
 		if err != nil {
@@ -14,6 +14,8 @@ import (
 const (
 	ScopeActivation     = "activation"
 	ScopeAuthentication = "authentication"
+	// ScopeNotificationStream tokens only open a notification stream
+	ScopeNotificationStream = "notification-stream"
 )
 
 type Token struct {
@@ -18,6 +18,9 @@ type User struct {
 	ID            int64     `json:"id"`
 	CreatedAt     time.Time `json:"created_at"`
 	Name          string    `json:"name"`
+	DisplayName   string    `json:"display_name"`
+	Bio           string    `json:"bio"`
+	AvatarURL     string    `json:"avatar_url"`
 	Email         string    `json:"email"`
 	FavoriteBooks []string  `json:"favorite_books"`
 	Password      password  `json:"-"`
@@ -65,6 +68,9 @@ func ValidatePasswordPlaintext(v *validator.Validator, password string) {
 func ValidateUser(v *validator.Validator, user *User) {
 	v.Check(user.Name != "", "name", "must be provided")
 	v.Check(len(user.Name) <= 500, "name", "must not be more than 500 bytes long")
+	v.Check(len(user.DisplayName) <= 100, "display_name", "must not be more than 100 bytes long")
+	v.Check(len(user.Bio) <= 2000, "bio", "must not be more than 2000 bytes long")
+	v.Check(len(user.AvatarURL) <= 2000, "avatar_url", "must not be more than 2000 bytes long")
 	ValidateEmail(v, user.Email)
 
 	if user.Password.plaintext != nil {
@@ -107,7 +113,7 @@ func (m UserModel) Insert(user *User) error {
 
 func (m UserModel) GetByEmail(email string) (*User, error) {
 	query := `
-		SELECT id, created_at, name, email, password_hash,is_admin, activated, version
+		SELECT id, created_at, name, display_name, bio, avatar_url, email, password_hash,is_admin, activated, version
 		FROM users
 		WHERE email = $1`
 	var user User
@@ -117,6 +123,45 @@ func (m UserModel) GetByEmail(email string) (*User, error) {
 		&user.ID,
 		&user.CreatedAt,
 		&user.Name,
+		&user.DisplayName,
+		&user.Bio,
+		&user.AvatarURL,
+		&user.Email,
+		&user.Password.hash,
+		&user.Is_admin,
+		&user.Activated,
+		&user.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &user, nil
+}
+
+func (m UserModel) Get(id int64) (*User, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, created_at, name, display_name, bio, avatar_url, email, password_hash, is_admin, activated, version
+		FROM users
+		WHERE id = $1`
+	var user User
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Name,
+		&user.DisplayName,
+		&user.Bio,
+		&user.AvatarURL,
 		&user.Email,
 		&user.Password.hash,
 		&user.Is_admin,
@@ -137,14 +182,17 @@ func (m UserModel) GetByEmail(email string) (*User, error) {
 func (m UserModel) Update(user *User) error {
 	query := `
 		UPDATE users
-		SET name = $1, email = $2, password_hash = $3, activated = $4, version = version + 1
-		WHERE id = $5 AND version = $6
+		SET name = $1, email = $2, password_hash = $3, activated = $4, display_name = $5, bio = $6, avatar_url = $7, version = version + 1
+		WHERE id = $8 AND version = $9
 		RETURNING version`
 	args := []interface{}{
 		user.Name,
 		user.Email,
 		user.Password.hash,
 		user.Activated,
+		user.DisplayName,
+		user.Bio,
+		user.AvatarURL,
 		user.ID,
 		user.Version,
 	}
@@ -168,7 +216,7 @@ func (m UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error)
 
 	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
 	query := `
-		SELECT users.id, users.created_at, users.name, users.email, users.password_hash, users.activated, users.version
+		SELECT users.id, users.created_at, users.name, users.display_name, users.bio, users.avatar_url, users.email, users.password_hash, users.activated, users.version
 		FROM users
 		INNER JOIN tokens
 		ON users.id = tokens.user_id
@@ -185,6 +233,9 @@ func (m UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error)
 		&user.ID,
 		&user.CreatedAt,
 		&user.Name,
+		&user.DisplayName,
+		&user.Bio,
+		&user.AvatarURL,
 		&user.Email,
 		&user.Password.hash,
 		&user.Activated,
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+const (
+	ActivityRating   = "rating"
+	ActivityComment  = "comment"
+	ActivityFavorite = "favorite"
+)
+
+// ActivityEvent records that a user rated, commented on or favorited a book.
+// SubjectID is the id of the rating, comment or favorite that was written.
+type ActivityEvent struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	UserName  string    `json:"user_name,omitempty"`
+	Type      string    `json:"type"`
+	BookID    *int64    `json:"book_id"`
+	BookTitle string    `json:"book_title,omitempty"`
+	SubjectID int64     `json:"subject_id"`
+	Score     *int      `json:"score,omitempty"`
+	Content   *string   `json:"content,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// Author is a person credited on books. Aliases are the spellings the author
+// has been credited under, BookCount is the number of books crediting them.
+type Author struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	Aliases   []string  `json:"aliases"`
+	Bio       string    `json:"bio"`
+	BookCount int       `json:"book_count"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int32     `json:"version"`
+}
@@ -11,5 +11,18 @@ type Book struct {
 	Rating      float64 `json:"rating"`
 	PeopleRated int64   `json:"people_rated"`
 	URL         string  `json:"url"`
+	ISBN10      string  `json:"isbn_10"`
+	ISBN13      string  `json:"isbn_13"`
+	ASIN        string  `json:"asin"`
+	Slug        string  `json:"slug"`
 	Version     int32   `json:"version"`
+
+	// Cover is nil until an editor uploads a cover image
+	Cover *Cover `json:"cover"`
+
+	// Editions are the formats the book is available in, set when showing or listing books
+	Editions []*Edition `json:"editions,omitempty"`
+
+	// WeightedRating is the rating shrunk towards the genre mean, set when listing books
+	WeightedRating *float64 `json:"weighted_rating,omitempty"`
 }
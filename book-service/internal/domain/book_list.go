@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// BookList is an ordered, titled collection of books. Lists without a UserID
+// are editorial lists maintained by users with the lists:feature permission.
+type BookList struct {
+	ID            int64     `json:"id"`
+	UserID        *int64    `json:"user_id"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	IsPublic      bool      `json:"is_public"`
+	IsFeatured    bool      `json:"is_featured"`
+	FeaturedGenre string    `json:"featured_genre,omitempty"`
+	BookIDs       []int64   `json:"book_ids"`
+	Books         []*Book   `json:"books,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Version       int32     `json:"version"`
+}
@@ -6,7 +6,9 @@ type Comment struct {
 	ID        int64     `json:"id"`
 	BookID    int64     `json:"book_id"`
 	UserID    int64     `json:"user_id"`
+	ParentID  *int64    `json:"parent_id"`
 	Content   string    `json:"content"`
+	Likes     int64     `json:"likes"`
 	CreatedAt time.Time `json:"created_at"`
 	Version   int32     `json:"version"`
 }
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Cover is a book's cover image. Thumbnails maps a size name such as "small"
+// to the URL of a JPEG of that width.
+type Cover struct {
+	URL         string            `json:"url"`
+	Thumbnails  map[string]string `json:"thumbnails"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	ContentType string            `json:"content_type"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+
+	// Keys are the storage keys of the original and every thumbnail
+	Keys []string `json:"-"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// Edition is one format of a book, such as its paperback or Kindle edition.
+// The primary edition's type, price and URL are also the book's own.
+type Edition struct {
+	ID        int64     `json:"id"`
+	BookID    int64     `json:"book_id"`
+	Type      string    `json:"type"`
+	Price     string    `json:"price"`
+	URL       string    `json:"url"`
+	ISBN10    string    `json:"isbn_10"`
+	ISBN13    string    `json:"isbn_13"`
+	ASIN      string    `json:"asin"`
+	IsPrimary bool      `json:"is_primary"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int32     `json:"version"`
+}
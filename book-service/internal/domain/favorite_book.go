@@ -10,4 +10,8 @@ type FavoriteBook struct {
 	UserID    int64     `json:"user_id"`
 	BookName  string    `json:"book_name"`
 	CreatedAt time.Time `json:"created_at"`
+
+	// PriceAlertBelow is the price the owner wants to be told about, if any
+	PriceAlertBelow       *float64   `json:"price_alert_below"`
+	PriceAlertTriggeredAt *time.Time `json:"price_alert_triggered_at"`
 }
@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+// Follow is a user in somebody's followers or following list
+type Follow struct {
+	UserID     int64     `json:"user_id"`
+	Name       string    `json:"name"`
+	FollowedAt time.Time `json:"followed_at"`
+}
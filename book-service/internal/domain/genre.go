@@ -5,5 +5,6 @@ type Genre struct {
 	Title         string `json:"title"`
 	SubgenreCount int    `json:"subgenre_count"`
 	URL           string `json:"url"`
+	Slug          string `json:"slug"`
 	Version       int32  `json:"version"`
 }
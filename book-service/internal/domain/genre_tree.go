@@ -0,0 +1,22 @@
+package domain
+
+// GenreNode is a genre with its sub-genres nested inside.
+// BookCount is the live number of books in the genre.
+type GenreNode struct {
+	ID        int64           `json:"id"`
+	Title     string          `json:"title"`
+	Slug      string          `json:"slug"`
+	BookCount int             `json:"book_count"`
+	Children  []*CategoryNode `json:"children"`
+}
+
+// CategoryNode is a sub-genre at any depth of the tree. BookCount includes the
+// books of every descendant.
+type CategoryNode struct {
+	ID        int64           `json:"id"`
+	Title     string          `json:"title"`
+	Slug      string          `json:"slug"`
+	ParentID  *int64          `json:"parent_id"`
+	BookCount int             `json:"book_count"`
+	Children  []*CategoryNode `json:"children"`
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+const (
+	NotificationCommentReply = "comment_reply"
+	NotificationCommentLike  = "comment_like"
+	NotificationPriceDrop    = "price_drop"
+	NotificationNewBook      = "new_book"
+)
+
+// Notification tells a user that something happened that concerns them.
+// SubjectID is the reply, liked comment, favorite or new book it is about.
+type Notification struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	Type      string     `json:"type"`
+	ActorID   *int64     `json:"actor_id"`
+	BookID    *int64     `json:"book_id"`
+	SubjectID int64      `json:"subject_id"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationEvent is published by handlers when something happened that
+// users may need to hear about. Who is notified is decided from the event:
+//
+//   - comment_reply: the author of the comment TargetID, SubjectID is the reply
+//   - comment_like: the author of the comment SubjectID
+//   - price_drop: UserID, SubjectID is their favorite
+//   - new_book: the followers of the authors of BookID
+//
+// The actor is never notified about their own actions.
+type NotificationEvent struct {
+	Type      string
+	ActorID   int64
+	UserID    int64
+	BookID    int64
+	SubjectID int64
+	TargetID  int64
+	Message   string
+}
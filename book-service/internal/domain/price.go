@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// PricePoint is a price an edition was seen at. Amount is the numeric part of
+// Price, nil when the price could not be read as a number.
+type PricePoint struct {
+	ID          int64     `json:"id"`
+	BookID      int64     `json:"book_id"`
+	EditionID   *int64    `json:"edition_id"`
+	EditionType string    `json:"edition_type"`
+	Price       string    `json:"price"`
+	Amount      *float64  `json:"amount"`
+	Source      string    `json:"source"`
+	RecordedAt  time.Time `json:"recorded_at"`
+}
+
+// PriceObservation is one price reported by an import run. The edition is
+// found by URL, or by book and edition type, or is the book's primary edition.
+type PriceObservation struct {
+	BookID int64  `json:"book_id"`
+	URL    string `json:"url"`
+	Type   string `json:"type"`
+	Price  string `json:"price"`
+}
+
+// PriceImport summarises an import run
+type PriceImport struct {
+	Updated   int   `json:"updated"`
+	Unchanged int   `json:"unchanged"`
+	Unmatched []int `json:"unmatched"`
+
+	// BookIDs are the books whose prices changed
+	BookIDs []int64 `json:"-"`
+}
+
+// PriceAlert is a favorite whose price-drop threshold was reached
+type PriceAlert struct {
+	FavoriteID int64   `json:"favorite_id"`
+	UserID     int64   `json:"user_id"`
+	BookID     int64   `json:"book_id"`
+	Title      string  `json:"title"`
+	Threshold  float64 `json:"threshold"`
+	Amount     float64 `json:"amount"`
+}
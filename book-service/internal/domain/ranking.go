@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+// RankedBook is a book's position in a precomputed ranking
+type RankedBook struct {
+	Rank       int       `json:"rank"`
+	Score      float64   `json:"score"`
+	Book       *Book     `json:"book"`
+	ComputedAt time.Time `json:"computed_at"`
+}
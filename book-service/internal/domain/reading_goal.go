@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// ReadingGoal is the number of books a user wants to finish in a given year
+type ReadingGoal struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Year      int       `json:"year"`
+	Target    int       `json:"target"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int32     `json:"version"`
+}
@@ -0,0 +1,34 @@
+package domain
+
+// ReadingStats summarises a user's reading activity for one year.
+// A book counts as finished the first time the user rates it, comments on it
+// or adds it to their favorites.
+type ReadingStats struct {
+	Year                 int             `json:"year"`
+	BooksFinished        int             `json:"books_finished"`
+	FinishedPerMonth     []PeriodCount   `json:"finished_per_month"`
+	FinishedPerYear      []PeriodCount   `json:"finished_per_year"`
+	GenreDistribution    []CategoryCount `json:"genre_distribution"`
+	SubGenreDistribution []CategoryCount `json:"sub_genre_distribution"`
+	AverageRatingGiven   float64         `json:"average_rating_given"`
+	RatingsGiven         int             `json:"ratings_given"`
+	Goal                 *GoalProgress   `json:"goal"`
+}
+
+type PeriodCount struct {
+	Period int `json:"period"`
+	Count  int `json:"count"`
+}
+
+type CategoryCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type GoalProgress struct {
+	Target    int     `json:"target"`
+	Finished  int     `json:"finished"`
+	Remaining int     `json:"remaining"`
+	Percent   float64 `json:"percent"`
+	OnTrack   bool    `json:"on_track"`
+}
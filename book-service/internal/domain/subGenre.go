@@ -6,5 +6,7 @@ type SubGenre struct {
 	MainGenre string  `json:"main_genre"`
 	BookCount float64 `json:"book_count"`
 	URL       string  `json:"url"`
+	Slug      string  `json:"slug"`
+	ParentID  *int64  `json:"parent_id"`
 	Version   int32   `json:"version"`
 }
@@ -0,0 +1,122 @@
+// Package imaging decodes uploaded cover images and renders thumbnails using
+// only the standard library image codecs.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("imaging: unsupported image format")
+	ErrTooLarge          = errors.New("imaging: image dimensions are too large")
+)
+
+// MaxPixels bounds width*height so a small compressed file cannot expand into
+// an enormous bitmap while decoding
+const MaxPixels = 40_000_000
+
+// ContentTypes maps the accepted upload content types to their file extension
+var ContentTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// Decode checks the dimensions of an encoded image before decoding it
+func Decode(data []byte) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, "", ErrUnsupportedFormat
+		}
+		return nil, "", err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	return img, format, nil
+}
+
+// Thumbnail scales img down to width pixels wide, keeping the aspect ratio.
+// Images narrower than width are not enlarged.
+func Thumbnail(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= width {
+		dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+		return dst
+	}
+
+	height := b.Dy() * width / b.Dx()
+	if height < 1 {
+		height = 1
+	}
+	return resize(img, width, height)
+}
+
+// resize averages every source pixel covered by each destination pixel, which
+// gives smooth results for the downscaling thumbnails need
+func resize(img image.Image, width, height int) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := b.Min.Y + (y+1)*b.Dy()/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := b.Min.X + (x+1)*b.Dx()/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					// composite onto white so transparent PNGs and GIFs don't turn black in JPEG
+					c := color.NRGBA64Model.Convert(img.At(sx, sy)).(color.NRGBA64)
+					a := uint64(c.A)
+					r += (uint64(c.R)*a + 0xffff*(0xffff-a)) / 0xffff
+					g += (uint64(c.G)*a + 0xffff*(0xffff-a)) / 0xffff
+					bl += (uint64(c.B)*a + 0xffff*(0xffff-a)) / 0xffff
+					n++
+				}
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: 0xff,
+			})
+		}
+	}
+
+	return dst
+}
+
+// EncodeJPEG encodes img as a JPEG of the given quality
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,96 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// pngHeader returns the start of a PNG that claims the given dimensions but
+// carries no pixel data, enough for image.DecodeConfig
+func pngHeader(width, height uint32) []byte {
+	var ihdr bytes.Buffer
+	ihdr.WriteString("IHDR")
+	binary.Write(&ihdr, binary.BigEndian, width)
+	binary.Write(&ihdr, binary.BigEndian, height)
+	// 8 bit truecolor, default compression, filter and interlacing
+	ihdr.Write([]byte{8, 2, 0, 0, 0})
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(ihdr.Len()-4))
+	buf.Write(ihdr.Bytes())
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(ihdr.Bytes()))
+	return buf.Bytes()
+}
+
+func encode(t *testing.T, format string) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+		err    error
+	}{
+		{"png", encode(t, "png"), "png", nil},
+		{"jpeg", encode(t, "jpeg"), "jpeg", nil},
+		{"gif", encode(t, "gif"), "gif", nil},
+		{"one pixel over the limit", pngHeader(1, MaxPixels+1), "", ErrTooLarge},
+		{"wide and tall", pngHeader(40_000, 1_001), "", ErrTooLarge},
+		{"ten gigapixels", pngHeader(100_000, 100_000), "", ErrTooLarge},
+		{"not an image", []byte("GIF87 is not enough"), "", ErrUnsupportedFormat},
+		{"empty", nil, "", ErrUnsupportedFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, format, err := Decode(tt.data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Decode() error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if format != tt.format {
+				t.Errorf("Decode() format = %q, want %q", format, tt.format)
+			}
+			if b := img.Bounds(); b.Dx() != 4 || b.Dy() != 3 {
+				t.Errorf("Decode() bounds = %v, want 4x3", b)
+			}
+		})
+	}
+}
+
+// TestDecodeAtLimit checks that an image of exactly MaxPixels passes the
+// dimension check; the header carries no pixels, so decoding then fails
+func TestDecodeAtLimit(t *testing.T) {
+	_, _, err := Decode(pngHeader(40_000, MaxPixels/40_000))
+	if err == nil || errors.Is(err, ErrTooLarge) || errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Decode() error = %v, want a decoding error", err)
+	}
+}
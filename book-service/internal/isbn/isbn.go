@@ -0,0 +1,139 @@
+package isbn
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalid = errors.New("is not a valid ISBN-10 or ISBN-13")
+	// ErrNoISBN10 is returned when converting an ISBN-13 outside the 978 range,
+	// which has no ISBN-10 form.
+	ErrNoISBN10 = errors.New("has no ISBN-10 form")
+)
+
+var (
+	// asinRX finds the product identifier in Amazon URLs such as
+	// https://www.amazon.in/Black-Holes/dp/085750357X/ref=...
+	asinRX = regexp.MustCompile(`/(?:dp|gp/product|gp/aw/d)/([A-Z0-9]{10})(?:[/?]|$)`)
+)
+
+// Normalize removes the hyphens and spaces ISBNs are often printed with and
+// upper-cases a trailing x.
+func Normalize(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	return strings.NewReplacer("-", "", " ", "").Replace(s)
+}
+
+// Valid10 reports whether s is an ISBN-10 with a correct check digit
+func Valid10(s string) bool {
+	if len(s) != 10 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 10; i++ {
+		c := s[i]
+		var d int
+		switch {
+		case c >= '0' && c <= '9':
+			d = int(c - '0')
+		case c == 'X' && i == 9:
+			d = 10
+		default:
+			return false
+		}
+		sum += (10 - i) * d
+	}
+	return sum%11 == 0
+}
+
+// Valid13 reports whether s is an ISBN-13 with a correct check digit
+func Valid13(s string) bool {
+	if len(s) != 13 || !(strings.HasPrefix(s, "978") || strings.HasPrefix(s, "979")) {
+		return false
+	}
+	for i := 0; i < 13; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return checkDigit13(s[:12]) == s[12]
+}
+
+// To13 converts a valid ISBN-10 to its ISBN-13 form
+func To13(isbn10 string) (string, error) {
+	if !Valid10(isbn10) {
+		return "", ErrInvalid
+	}
+	body := "978" + isbn10[:9]
+	return body + string(checkDigit13(body)), nil
+}
+
+// To10 converts a valid ISBN-13 from the 978 range to its ISBN-10 form
+func To10(isbn13 string) (string, error) {
+	if !Valid13(isbn13) {
+		return "", ErrInvalid
+	}
+	if !strings.HasPrefix(isbn13, "978") {
+		return "", ErrNoISBN10
+	}
+	body := isbn13[3:12]
+	return body + string(checkDigit10(body)), nil
+}
+
+// Parse accepts either form and returns both. isbn10 is empty for ISBN-13s
+// that have no ISBN-10 form.
+func Parse(s string) (isbn10, isbn13 string, err error) {
+	s = Normalize(s)
+	switch {
+	case Valid10(s):
+		isbn13, _ = To13(s)
+		return s, isbn13, nil
+	case Valid13(s):
+		isbn10, _ = To10(s)
+		return isbn10, s, nil
+	default:
+		return "", "", ErrInvalid
+	}
+}
+
+// FromAmazonURL extracts the ASIN from an Amazon product URL. Amazon uses the
+// ISBN-10 as the ASIN of printed books, so when the ASIN is a valid ISBN-10
+// both ISBN forms are returned as well.
+func FromAmazonURL(url string) (asin, isbn10, isbn13 string) {
+	match := asinRX.FindStringSubmatch(url)
+	if match == nil {
+		return "", "", ""
+	}
+	asin = match[1]
+	if Valid10(asin) {
+		isbn13, _ = To13(asin)
+		return asin, asin, isbn13
+	}
+	return asin, "", ""
+}
+
+func checkDigit13(body string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(body[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func checkDigit10(body string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += (10 - i) * int(body[i]-'0')
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
@@ -0,0 +1,149 @@
+package isbn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValid10(t *testing.T) {
+	tests := []struct {
+		name  string
+		isbn  string
+		valid bool
+	}{
+		{"valid", "0306406152", true},
+		{"check digit X", "080442957X", true},
+		{"Amazon ASIN", "085750357X", true},
+		{"wrong check digit", "0306406153", false},
+		{"lowercase x", "080442957x", false},
+		{"X before the end", "08044295X7", false},
+		{"too short", "030640615", false},
+		{"too long", "03064061520", false},
+		{"letters", "03064O6152", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Valid10(tt.isbn); got != tt.valid {
+				t.Errorf("Valid10(%q) = %v, want %v", tt.isbn, got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValid13(t *testing.T) {
+	tests := []struct {
+		name  string
+		isbn  string
+		valid bool
+	}{
+		{"978 range", "9780306406157", true},
+		{"979 range", "9791090636071", true},
+		{"wrong check digit", "9780306406158", false},
+		{"not a book prefix", "9770306406155", false},
+		{"X check digit", "978030640615X", false},
+		{"too short", "978030640615", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Valid13(tt.isbn); got != tt.valid {
+				t.Errorf("Valid13(%q) = %v, want %v", tt.isbn, got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestConversion(t *testing.T) {
+	tests := []struct {
+		isbn10 string
+		isbn13 string
+	}{
+		{"0306406152", "9780306406157"},
+		{"080442957X", "9780804429573"},
+		{"085750357X", "9780857503572"},
+		{"0000000000", "9780000000002"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.isbn10, func(t *testing.T) {
+			isbn13, err := To13(tt.isbn10)
+			if err != nil || isbn13 != tt.isbn13 {
+				t.Errorf("To13(%q) = %q, %v, want %q", tt.isbn10, isbn13, err, tt.isbn13)
+			}
+
+			isbn10, err := To10(tt.isbn13)
+			if err != nil || isbn10 != tt.isbn10 {
+				t.Errorf("To10(%q) = %q, %v, want %q", tt.isbn13, isbn10, err, tt.isbn10)
+			}
+		})
+	}
+}
+
+func TestConversionErrors(t *testing.T) {
+	if _, err := To13("0306406153"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("To13 of a bad check digit: got %v, want ErrInvalid", err)
+	}
+	if _, err := To10("9780306406158"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("To10 of a bad check digit: got %v, want ErrInvalid", err)
+	}
+	if _, err := To10("9791090636071"); !errors.Is(err, ErrNoISBN10) {
+		t.Errorf("To10 of a 979 ISBN: got %v, want ErrNoISBN10", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		isbn10 string
+		isbn13 string
+		err    error
+	}{
+		{"ISBN-10 with hyphens", "0-306-40615-2", "0306406152", "9780306406157", nil},
+		{"ISBN-10 with lowercase x", " 0-8044-2957-x ", "080442957X", "9780804429573", nil},
+		{"ISBN-13 with spaces", "978 0 306 40615 7", "0306406152", "9780306406157", nil},
+		{"ISBN-13 without ISBN-10", "979-10-90636-07-1", "", "9791090636071", nil},
+		{"invalid", "123456789", "", "", ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isbn10, isbn13, err := Parse(tt.input)
+			if isbn10 != tt.isbn10 || isbn13 != tt.isbn13 || !errors.Is(err, tt.err) {
+				t.Errorf("Parse(%q) = %q, %q, %v, want %q, %q, %v", tt.input, isbn10, isbn13, err, tt.isbn10, tt.isbn13, tt.err)
+			}
+		})
+	}
+}
+
+func TestFromAmazonURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		asin   string
+		isbn10 string
+		isbn13 string
+	}{
+		{"dp with ISBN ASIN", "https://www.amazon.in/Black-Holes/dp/085750357X/ref=sr_1_1", "085750357X", "085750357X", "9780857503572"},
+		{"dp at the end", "https://www.amazon.in/dp/0306406152", "0306406152", "0306406152", "9780306406157"},
+		{"dp with a query", "https://www.amazon.in/dp/0306406152?tag=x", "0306406152", "0306406152", "9780306406157"},
+		{"gp/product", "https://www.amazon.in/gp/product/0306406152/", "0306406152", "0306406152", "9780306406157"},
+		{"Kindle ASIN", "https://www.amazon.in/Some-Book-ebook/dp/B07XYZ1234/ref=x", "B07XYZ1234", "", ""},
+		{"ASIN that is not an ISBN", "https://www.amazon.in/dp/0306406153/", "0306406153", "", ""},
+		{"too long", "https://www.amazon.in/dp/0306406152X/", "", "", ""},
+		{"no product", "https://www.amazon.in/s?k=black+holes", "", "", ""},
+		{"empty", "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asin, isbn10, isbn13 := FromAmazonURL(tt.url)
+			if asin != tt.asin || isbn10 != tt.isbn10 || isbn13 != tt.isbn13 {
+				t.Errorf("FromAmazonURL(%q) = %q, %q, %q, want %q, %q, %q", tt.url, asin, isbn10, isbn13, tt.asin, tt.isbn10, tt.isbn13)
+			}
+		})
+	}
+}
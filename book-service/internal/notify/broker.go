@@ -0,0 +1,88 @@
+// Package notify turns events published by the handlers into stored
+// notifications and delivers them live to connected users.
+package notify
+
+import (
+	"book-service/internal/domain"
+	"sync"
+)
+
+// subscriberBuffer is how many notifications a slow stream may fall behind
+// before it starts missing them. Missed notifications are still stored.
+const subscriberBuffer = 16
+
+// Broker fans notifications out to the live streams of their recipients
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan *domain.Notification]struct{}
+	closed      bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: map[int64]map[chan *domain.Notification]struct{}{}}
+}
+
+// Subscribe returns a channel receiving the user's new notifications and a
+// function that unsubscribes. The channel is closed when the broker closes.
+func (b *Broker) Subscribe(userID int64) (<-chan *domain.Notification, func()) {
+	ch := make(chan *domain.Notification, subscriberBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = map[chan *domain.Notification]struct{}{}
+	}
+	b.subscribers[userID][ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[userID][ch]; !ok {
+			return
+		}
+		delete(b.subscribers[userID], ch)
+		if len(b.subscribers[userID]) == 0 {
+			delete(b.subscribers, userID)
+		}
+		close(ch)
+	}
+}
+
+// Send delivers the notification to every stream of its recipient without
+// blocking
+func (b *Broker) Send(notification *domain.Notification) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[notification.UserID] {
+		select {
+		case ch <- notification:
+		default:
+		}
+	}
+}
+
+// Close ends every stream
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+
+	for _, channels := range b.subscribers {
+		for ch := range channels {
+			close(ch)
+		}
+	}
+	b.subscribers = nil
+}
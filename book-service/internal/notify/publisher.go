@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"book-service/internal/data"
+	"book-service/internal/domain"
+	"book-service/internal/jsonlog"
+	"errors"
+	"sync"
+)
+
+// queueSize bounds the events waiting to be processed. Handlers never wait
+// on the queue, events published while it is full are dropped and logged.
+const queueSize = 256
+
+var ErrPublisherClosed = errors.New("notify: publisher is closed")
+
+// Publisher is how handlers emit notification events. A background worker
+// stores a notification for every recipient and sends it to the broker.
+type Publisher struct {
+	notifications data.NotificationModel
+	broker        *Broker
+	logger        *jsonlog.Logger
+
+	mu     sync.RWMutex
+	events chan *domain.NotificationEvent
+	closed bool
+	done   chan struct{}
+}
+
+func NewPublisher(notifications data.NotificationModel, broker *Broker, logger *jsonlog.Logger) *Publisher {
+	p := &Publisher{
+		notifications: notifications,
+		broker:        broker,
+		logger:        logger,
+		events:        make(chan *domain.NotificationEvent, queueSize),
+		done:          make(chan struct{}),
+	}
+
+	go p.run()
+
+	return p
+}
+
+// Publish queues the event without blocking
+func (p *Publisher) Publish(event *domain.NotificationEvent) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		p.logger.PrintError(ErrPublisherClosed, map[string]string{"type": event.Type})
+		return
+	}
+
+	select {
+	case p.events <- event:
+	default:
+		p.logger.PrintError(errors.New("notify: event queue is full, event dropped"), map[string]string{"type": event.Type})
+	}
+}
+
+// Close stops accepting events and waits for the queued ones to be delivered
+func (p *Publisher) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.events)
+	}
+	p.mu.Unlock()
+
+	<-p.done
+}
+
+func (p *Publisher) run() {
+	defer close(p.done)
+
+	for event := range p.events {
+		p.deliver(event)
+	}
+}
+
+func (p *Publisher) deliver(event *domain.NotificationEvent) {
+	defer func() {
+		if err := recover(); err != nil {
+			p.logger.PrintError(errors.New("notify: panic delivering event"), map[string]string{"type": event.Type})
+		}
+	}()
+
+	notifications, err := p.notifications.InsertForEvent(event)
+	if err != nil {
+		p.logger.PrintError(err, map[string]string{"type": event.Type})
+		return
+	}
+
+	for _, notification := range notifications {
+		p.broker.Send(notification)
+	}
+}
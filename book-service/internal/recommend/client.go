@@ -0,0 +1,155 @@
+// Package recommend is the client of the recommendation service
+// (book-recomendation). It reports user interactions the service learns
+// preferences from and passes on the writes of signed in users.
+package recommend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Interaction types accepted by the recommendation service
+const (
+	EventView          = "view"
+	EventFavorite      = "favorite"
+	EventRate          = "rate"
+	EventComment       = "comment"
+	EventPurchaseClick = "purchase_click"
+)
+
+// Event is a user's interaction with a book. Favorites only know the book's
+// title, so BookTitle may be sent instead of BookID.
+type Event struct {
+	UserID    int64   `json:"user_id"`
+	BookID    int64   `json:"book_id,omitempty"`
+	BookTitle string  `json:"book_title,omitempty"`
+	Type      string  `json:"interaction_type"`
+	Rating    float64 `json:"rating,omitempty"`
+}
+
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient returns a client of the recommendation service at baseURL, for
+// example http://go_recommendation_service:8080. The service only accepts
+// writes that carry its SERVICE_TOKEN, passed as token.
+func NewClient(baseURL, token string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+// ErrRejected is returned when the service refuses a request as invalid. The
+// error carries the service's message.
+var ErrRejected = errors.New("recommend: request rejected")
+
+// ErrNotFound is returned when the service has nothing at the path, such as
+// clicks posted while no experiment is running
+var ErrNotFound = errors.New("recommend: not found")
+
+// Onboarding is what a new user picked in the onboarding questionnaire
+type Onboarding struct {
+	MainGenres []string `json:"main_genres"`
+	SubGenres  []string `json:"sub_genres"`
+	Authors    []string `json:"authors"`
+	BookIDs    []int64  `json:"book_ids"`
+}
+
+// Preferences are a user's weights per attribute ("Main Genre", "Sub Genre",
+// "Type" or "Author") and value
+type Preferences map[string]map[string]float64
+
+// Assignment is the variant of an experiment a user is bucketed into
+type Assignment struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+}
+
+// SendEvent posts the event to the service's /events endpoint
+func (c *Client) SendEvent(ctx context.Context, event Event) error {
+	err := c.post(ctx, "/events", event, nil)
+	if err != nil {
+		return fmt.Errorf("recommend: sending %s event: %w", event.Type, err)
+	}
+
+	return nil
+}
+
+// SubmitOnboarding seeds the user's preferences from their questionnaire and
+// returns them
+func (c *Client) SubmitOnboarding(ctx context.Context, userID int64, selection Onboarding) (Preferences, error) {
+	var prefs Preferences
+	err := c.post(ctx, fmt.Sprintf("/users/%d/onboarding", userID), selection, &prefs)
+	if err != nil {
+		return nil, fmt.Errorf("recommend: submitting onboarding: %w", err)
+	}
+
+	return prefs, nil
+}
+
+// LogClick counts the user's click on a recommended book for their variant of
+// the running experiment
+func (c *Client) LogClick(ctx context.Context, userID, bookID int64) (*Assignment, error) {
+	click := struct {
+		UserID int64 `json:"user_id"`
+		BookID int64 `json:"book_id"`
+	}{userID, bookID}
+
+	var assignment Assignment
+	err := c.post(ctx, "/experiments/clicks", click, &assignment)
+	if err != nil {
+		return nil, fmt.Errorf("recommend: logging click: %w", err)
+	}
+
+	return &assignment, nil
+}
+
+// post sends in as JSON to path and decodes the response into out, unless
+// out is nil. The service answers writes with 201 Created.
+func (c *Client) post(ctx context.Context, path string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Token", c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%w: %s", ErrRejected, strings.TrimSpace(string(message)))
+	default:
+		return fmt.Errorf("%s rejected: %s", path, resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,96 @@
+package recommend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSubmitOnboarding(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    Preferences
+		wantErr error
+	}{
+		{"seeded", http.StatusCreated, `{"Main Genre":{"Fiction":0.8}}`, Preferences{"Main Genre": {"Fiction": 0.8}}, nil},
+		{"rejected selection", http.StatusBadRequest, "unknown genre\n", nil, ErrRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/users/7/onboarding" {
+					t.Errorf("got %s %s, want POST /users/7/onboarding", r.Method, r.URL.Path)
+				}
+				if got := r.Header.Get("X-Service-Token"); got != "secret" {
+					t.Errorf("X-Service-Token = %q, want %q", got, "secret")
+				}
+
+				var selection Onboarding
+				if err := json.NewDecoder(r.Body).Decode(&selection); err != nil || len(selection.MainGenres) != 1 {
+					t.Errorf("got selection %+v, %v", selection, err)
+				}
+
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL+"/", "secret", time.Second)
+			prefs, err := client.SubmitOnboarding(context.Background(), 7, Onboarding{MainGenres: []string{"Fiction"}})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SubmitOnboarding() error = %v, want %v", err, tt.wantErr)
+			}
+			if prefs["Main Genre"]["Fiction"] != tt.want["Main Genre"]["Fiction"] {
+				t.Errorf("SubmitOnboarding() = %v, want %v", prefs, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogClick(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr error
+	}{
+		{"counted", http.StatusCreated, `{"experiment":"weights","variant":"b"}`, "b", nil},
+		{"no experiment", http.StatusNotFound, "No experiment is running\n", "", ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var click struct {
+					UserID int64 `json:"user_id"`
+					BookID int64 `json:"book_id"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&click); err != nil || click.UserID != 7 || click.BookID != 42 {
+					t.Errorf("got click %+v, %v", click, err)
+				}
+
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			assignment, err := NewClient(server.URL, "secret", time.Second).LogClick(context.Background(), 7, 42)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LogClick() error = %v, want %v", err, tt.wantErr)
+			}
+			if assignment != nil && assignment.Variant != tt.want {
+				t.Errorf("LogClick() variant = %q, want %q", assignment.Variant, tt.want)
+			}
+		})
+	}
+}
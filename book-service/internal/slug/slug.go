@@ -0,0 +1,33 @@
+package slug
+
+import (
+	"strings"
+)
+
+// Make turns a title into a lowercase, URL-friendly slug, for example
+// "Arts, Film & Photography" becomes "arts-film-and-photography". Only ASCII
+// letters and digits are kept, everything else separates words.
+//
+// Keep this in step with the slugify function of migration 000019, which
+// backfilled the slugs of existing rows.
+func Make(title string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(strings.ReplaceAll(title, "&", " and ")) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		case r == '\'' || r == '’':
+			// drop apostrophes so "Children's" becomes "childrens"
+		default:
+			dash = true
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,35 @@
+// Package storage keeps uploaded files such as book covers. Files are
+// addressed by slash separated keys, for example "covers/12/3f9a/small.jpg".
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidKey = errors.New("storage: invalid key")
+)
+
+type Storage interface {
+	// Put stores data under key, replacing any existing file
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete removes the file stored under key. Deleting a missing file is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL the file stored under key is served from
+	URL(key string) string
+}
+
+// validKey rejects keys that could escape the storage root
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
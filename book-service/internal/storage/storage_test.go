@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"covers/12/3f9a/small.jpg", true},
+		{"original.png", true},
+		{"covers/..hidden/a.jpg", true},
+		{"", false},
+		{"/covers/12/a.jpg", false},
+		{"covers//a.jpg", false},
+		{"covers/12/", false},
+		{"covers/./a.jpg", false},
+		{"covers/../../etc/passwd", false},
+		{"..", false},
+		{`covers\..\a.jpg`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := validKey(tt.key); got != tt.valid {
+				t.Errorf("validKey(%q) = %v, want %v", tt.key, got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestFileSystem(t *testing.T) {
+	root := t.TempDir()
+	err := os.MkdirAll(filepath.Join(root, "covers", "1"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(root, "covers", "1", "small.jpg"), []byte("jpeg"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := FileSystem(root)
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"/covers/1/small.jpg", nil},
+		{"/", fs.ErrNotExist},
+		{"/covers", fs.ErrNotExist},
+		{"/covers/1/", fs.ErrNotExist},
+		{"/covers/1/large.jpg", fs.ErrNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := fsys.Open(tt.name)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Open(%q) error = %v, want %v", tt.name, err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			defer file.Close()
+
+			data, err := io.ReadAll(file)
+			if err != nil || string(data) != "jpeg" {
+				t.Errorf("Open(%q) read %q, %v", tt.name, data, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS reading_goals (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    year integer NOT NULL,
+    target integer NOT NULL CHECK (target > 0),
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1,
+    UNIQUE (user_id, year)
+);
+
+CREATE INDEX IF NOT EXISTS reading_goals_user_id_idx ON reading_goals(user_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS activity_events;
+DROP TABLE IF EXISTS follows;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX IF NOT EXISTS follows_followee_id_idx ON follows(followee_id);
+
+CREATE TABLE IF NOT EXISTS activity_events (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_type text NOT NULL,
+    book_id bigint REFERENCES books(id) ON DELETE CASCADE,
+    subject_id bigint NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS activity_events_user_id_created_at_idx ON activity_events(user_id, created_at DESC);
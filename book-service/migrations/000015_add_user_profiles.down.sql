@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS user_privacy_settings;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS display_name,
+    DROP COLUMN IF EXISTS bio,
+    DROP COLUMN IF EXISTS avatar_url;
@@ -0,0 +1,14 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS display_name text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS bio text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS avatar_url text NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS user_privacy_settings (
+    user_id bigint PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    show_bio boolean NOT NULL DEFAULT true,
+    show_avatar boolean NOT NULL DEFAULT true,
+    show_join_date boolean NOT NULL DEFAULT true,
+    show_stats boolean NOT NULL DEFAULT true,
+    show_shelves boolean NOT NULL DEFAULT true,
+    version integer NOT NULL DEFAULT 1
+);
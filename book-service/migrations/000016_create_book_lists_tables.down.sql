@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE code = 'lists:feature';
+DROP TABLE IF EXISTS book_list_items;
+DROP TABLE IF EXISTS book_lists;
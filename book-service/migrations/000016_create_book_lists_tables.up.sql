@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS book_lists (
+    id bigserial PRIMARY KEY,
+    user_id bigint REFERENCES users(id) ON DELETE CASCADE,
+    title text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    is_public boolean NOT NULL DEFAULT true,
+    is_featured boolean NOT NULL DEFAULT false,
+    featured_genre text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS book_lists_user_id_idx ON book_lists(user_id);
+CREATE INDEX IF NOT EXISTS book_lists_featured_genre_idx ON book_lists(featured_genre) WHERE is_featured;
+
+CREATE TABLE IF NOT EXISTS book_list_items (
+    list_id bigint NOT NULL REFERENCES book_lists(id) ON DELETE CASCADE,
+    book_id bigint NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    position integer NOT NULL,
+    PRIMARY KEY (list_id, book_id)
+);
+
+INSERT INTO permissions (code)
+VALUES ('lists:feature');
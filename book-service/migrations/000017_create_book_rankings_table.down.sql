@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS user_favorite_books_created_at_idx;
+DROP INDEX IF EXISTS comments_created_at_idx;
+DROP INDEX IF EXISTS ratings_created_at_idx;
+DROP TABLE IF EXISTS book_rankings;
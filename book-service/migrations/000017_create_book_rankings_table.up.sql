@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS book_rankings (
+    kind text NOT NULL,
+    genre text NOT NULL DEFAULT '',
+    sub_genre text NOT NULL DEFAULT '',
+    rank integer NOT NULL,
+    book_id bigint NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    score double precision NOT NULL,
+    computed_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (kind, genre, sub_genre, rank)
+);
+
+CREATE INDEX IF NOT EXISTS ratings_created_at_idx ON ratings(created_at);
+CREATE INDEX IF NOT EXISTS comments_created_at_idx ON comments(created_at);
+CREATE INDEX IF NOT EXISTS user_favorite_books_created_at_idx ON user_favorite_books(created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE subgenres
+    DROP COLUMN IF EXISTS parent_id,
+    DROP COLUMN IF EXISTS genre_id;
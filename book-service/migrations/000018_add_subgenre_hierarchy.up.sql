@@ -0,0 +1,11 @@
+ALTER TABLE subgenres
+    ADD COLUMN IF NOT EXISTS genre_id integer REFERENCES genres(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS parent_id integer REFERENCES subgenres(id) ON DELETE CASCADE;
+
+UPDATE subgenres s
+SET genre_id = g.id
+FROM genres g
+WHERE g.title = s.main_genre AND s.genre_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS subgenres_genre_id_idx ON subgenres(genre_id);
+CREATE INDEX IF NOT EXISTS subgenres_parent_id_idx ON subgenres(parent_id);
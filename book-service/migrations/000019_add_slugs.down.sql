@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS slug_redirects;
+
+DROP INDEX IF EXISTS subgenres_slug_idx;
+DROP INDEX IF EXISTS genres_slug_idx;
+DROP INDEX IF EXISTS books_slug_idx;
+
+DROP FUNCTION IF EXISTS backfill_slugs(regclass, text);
+DROP FUNCTION IF EXISTS slugify(text);
+
+ALTER TABLE subgenres DROP COLUMN IF EXISTS slug;
+ALTER TABLE genres DROP COLUMN IF EXISTS slug;
+ALTER TABLE books DROP COLUMN IF EXISTS slug;
@@ -0,0 +1,45 @@
+ALTER TABLE books ADD COLUMN IF NOT EXISTS slug text;
+ALTER TABLE genres ADD COLUMN IF NOT EXISTS slug text;
+ALTER TABLE subgenres ADD COLUMN IF NOT EXISTS slug text;
+
+-- Same rules as slug.Make
+CREATE OR REPLACE FUNCTION slugify(title text) RETURNS text
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT trim(both '-' from regexp_replace(lower(replace(replace(replace(title, '&', ' and '), '''', ''), '’', '')), '[^a-z0-9]+', '-', 'g'))
+$$;
+
+-- Gives the rows of tbl without a slug the slugify of their source column.
+-- Duplicates get their id appended, rows without any usable characters fall
+-- back to "untitled" like uniqueSlug does.
+CREATE OR REPLACE FUNCTION backfill_slugs(tbl regclass, source text) RETURNS void
+LANGUAGE plpgsql AS $$
+BEGIN
+    EXECUTE format($q$
+        UPDATE %1$s SET slug = s.slug
+        FROM (
+            SELECT id, CASE
+                WHEN ROW_NUMBER() OVER (PARTITION BY base ORDER BY id) > 1 THEN base || '-' || id
+                ELSE base END AS slug
+            FROM (SELECT id, COALESCE(NULLIF(slugify(%2$I), ''), 'untitled') AS base FROM %1$s) t
+        ) s
+        WHERE %1$s.id = s.id AND %1$s.slug IS NULL
+    $q$, tbl, source);
+END
+$$;
+
+SELECT backfill_slugs('books', 'title');
+SELECT backfill_slugs('genres', 'title');
+SELECT backfill_slugs('subgenres', 'title');
+
+CREATE UNIQUE INDEX IF NOT EXISTS books_slug_idx ON books(slug);
+CREATE UNIQUE INDEX IF NOT EXISTS genres_slug_idx ON genres(slug);
+CREATE UNIQUE INDEX IF NOT EXISTS subgenres_slug_idx ON subgenres(slug);
+
+-- Old slugs keep resolving after a rename
+CREATE TABLE IF NOT EXISTS slug_redirects (
+    entity text NOT NULL,
+    slug text NOT NULL,
+    target_id bigint NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (entity, slug)
+);
@@ -0,0 +1,5 @@
+DELETE FROM slug_redirects WHERE entity = 'authors';
+
+DROP TABLE IF EXISTS book_authors;
+DROP TABLE IF EXISTS author_aliases;
+DROP TABLE IF EXISTS authors;
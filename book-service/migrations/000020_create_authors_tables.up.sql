@@ -0,0 +1,71 @@
+CREATE TABLE IF NOT EXISTS authors (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL,
+    name_key text NOT NULL UNIQUE,
+    slug text UNIQUE,
+    bio text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1
+);
+
+-- Every spelling an author has been credited under, matched by name_key
+CREATE TABLE IF NOT EXISTS author_aliases (
+    author_id bigint NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    alias text NOT NULL,
+    name_key text NOT NULL,
+    PRIMARY KEY (author_id, alias)
+);
+
+CREATE INDEX IF NOT EXISTS author_aliases_name_key_idx ON author_aliases(name_key);
+
+CREATE TABLE IF NOT EXISTS book_authors (
+    book_id bigint NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    author_id bigint NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    position integer NOT NULL,
+    PRIMARY KEY (book_id, author_id)
+);
+
+CREATE INDEX IF NOT EXISTS book_authors_author_id_idx ON book_authors(author_id);
+
+-- Split "A, B" and "A & B" credits, but not before suffixes such as the
+-- "Jr." of "Martin Luther King, Jr.", and group spellings that only differ
+-- in case, spacing or punctuation, the same way data.SplitAuthors and
+-- data.AuthorNameKey do. [:alnum:] follows the database locale, so letters
+-- of every script are kept under the usual UTF-8 locales.
+CREATE TEMPORARY TABLE credited AS
+SELECT b.id AS book_id, p.position, trim(p.name) AS name,
+    regexp_replace(lower(p.name), '[^[:alnum:]]+', '', 'g') AS name_key
+FROM books b
+CROSS JOIN LATERAL regexp_split_to_table(b.author,
+    '(?i)\s*[,&](?!\s*(jr|sr|ph\.?\s?d|m\.?d|ii|iii|iv)\.?\s*([,&]|$))\s*') WITH ORDINALITY AS p(name, position)
+WHERE b.author IS NOT NULL;
+
+DELETE FROM credited WHERE name_key = '';
+
+-- The most used spelling becomes the author's name
+INSERT INTO authors (name, name_key)
+SELECT DISTINCT ON (name_key) name, name_key
+FROM (
+    SELECT name, name_key, count(*) AS uses
+    FROM credited
+    GROUP BY name, name_key
+) spellings
+ORDER BY name_key, uses DESC, name
+ON CONFLICT (name_key) DO NOTHING;
+
+INSERT INTO author_aliases (author_id, alias, name_key)
+SELECT DISTINCT a.id, c.name, c.name_key
+FROM credited c
+INNER JOIN authors a ON a.name_key = c.name_key
+ON CONFLICT DO NOTHING;
+
+INSERT INTO book_authors (book_id, author_id, position)
+SELECT DISTINCT ON (c.book_id, a.id) c.book_id, a.id, c.position
+FROM credited c
+INNER JOIN authors a ON a.name_key = c.name_key
+ORDER BY c.book_id, a.id, c.position
+ON CONFLICT DO NOTHING;
+
+SELECT backfill_slugs('authors', 'name');
+
+DROP TABLE credited;
@@ -0,0 +1,4 @@
+-- Merging duplicate books into works cannot be undone: the merged rows are
+-- deleted and nothing they held is kept. Only the new tables are dropped.
+DROP TABLE IF EXISTS book_genres;
+DROP TABLE IF EXISTS editions;
@@ -0,0 +1,151 @@
+CREATE TABLE IF NOT EXISTS editions (
+    id bigserial PRIMARY KEY,
+    book_id bigint NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    type text NOT NULL DEFAULT '',
+    price text NOT NULL DEFAULT '',
+    url text NOT NULL DEFAULT '',
+    isbn text NOT NULL DEFAULT '',
+    is_primary boolean NOT NULL DEFAULT false,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS editions_book_id_idx ON editions(book_id);
+-- The primary edition is mirrored into books.type, books.price and books.url
+CREATE UNIQUE INDEX IF NOT EXISTS editions_primary_idx ON editions(book_id) WHERE is_primary;
+
+-- The genres a work is listed under besides its own main_genre and sub_genre,
+-- those of the rows merged into it
+CREATE TABLE IF NOT EXISTS book_genres (
+    book_id bigint NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    main_genre text NOT NULL,
+    sub_genre text NOT NULL DEFAULT '',
+    PRIMARY KEY (book_id, main_genre, sub_genre)
+);
+
+CREATE INDEX IF NOT EXISTS book_genres_main_genre_idx ON book_genres(main_genre, sub_genre);
+
+-- Rows with the same title and author are one work. Titles are compared
+-- without case, punctuation or a trailing "[Paperback] ..." marker, names the
+-- way data.AuthorNameKey does. Within a work, rows of the same type are the
+-- same edition listed under several genres. The lowest id becomes the work,
+-- and the lowest id of each type its edition.
+CREATE TEMPORARY TABLE work_groups AS
+SELECT id,
+    MIN(id) OVER (PARTITION BY work_key) AS work_id,
+    MIN(id) OVER (PARTITION BY work_key, format_key) AS edition_id,
+    FIRST_VALUE(id) OVER (PARTITION BY work_key ORDER BY people_rated DESC NULLS LAST, id) AS most_rated_id
+FROM (
+    SELECT id, people_rated,
+        regexp_replace(lower(regexp_replace(title, '\s*\[[^\]]*\].*$', '')), '[^[:alnum:]]+', '', 'g')
+            || '|' || regexp_replace(lower(COALESCE(author, '')), '[^[:alnum:]]+', '', 'g') AS work_key,
+        lower(trim(COALESCE(type, ''))) AS format_key
+    FROM books
+) keyed;
+
+INSERT INTO editions (book_id, type, price, url, is_primary)
+SELECT g.work_id, COALESCE(b.type, ''), COALESCE(b.price, ''), COALESCE(b.url, ''), b.id = g.work_id
+FROM books b
+INNER JOIN work_groups g ON g.id = b.id
+WHERE g.id = g.edition_id
+ORDER BY b.id;
+
+INSERT INTO book_genres (book_id, main_genre, sub_genre)
+SELECT DISTINCT g.work_id, d.main_genre, COALESCE(d.sub_genre, '')
+FROM work_groups g
+INNER JOIN books d ON d.id = g.id
+INNER JOIN books w ON w.id = g.work_id
+WHERE g.id <> g.work_id AND d.main_genre IS NOT NULL
+    AND (d.main_genre, COALESCE(d.sub_genre, '')) IS DISTINCT FROM (w.main_genre, COALESCE(w.sub_genre, ''));
+
+DELETE FROM work_groups g
+WHERE g.work_id = g.id AND NOT EXISTS (SELECT 1 FROM work_groups o WHERE o.work_id = g.work_id AND o.id <> o.work_id);
+
+-- The merge is not kept anywhere, so the down migration cannot undo it
+CREATE TEMPORARY TABLE merged_books AS
+SELECT b.* FROM books b INNER JOIN work_groups g ON g.id = b.id WHERE g.id <> g.work_id;
+
+-- Duplicate editions share their Amazon ratings, so the work keeps the figures
+-- of its most rated edition instead of adding them up.
+UPDATE books b
+SET rating = mr.rating, people_rated = mr.people_rated, version = COALESCE(b.version, 0) + 1
+FROM work_groups g
+INNER JOIN books mr ON mr.id = g.most_rated_id
+WHERE b.id = g.work_id AND g.id = g.work_id AND g.most_rated_id <> g.work_id;
+
+-- A user keeps their latest rating of the work; earlier ones of its other
+-- rows are dropped
+CREATE TEMPORARY TABLE merged_ratings AS
+SELECT r.*
+FROM ratings r
+INNER JOIN work_groups g ON g.id = r.book_id
+WHERE EXISTS (
+    SELECT 1 FROM ratings o
+    INNER JOIN work_groups go ON go.id = o.book_id
+    WHERE go.work_id = g.work_id AND o.user_id = r.user_id
+        AND (o.created_at > r.created_at OR (o.created_at = r.created_at AND o.id > r.id))
+);
+
+DELETE FROM ratings r USING merged_ratings m WHERE r.id = m.id;
+
+-- Move everything that points at a merged row over to its work
+UPDATE ratings r SET book_id = g.work_id FROM work_groups g WHERE r.book_id = g.id AND g.id <> g.work_id;
+UPDATE comments c SET book_id = g.work_id FROM work_groups g WHERE c.book_id = g.id AND g.id <> g.work_id;
+UPDATE like_comment l SET book_id = g.work_id FROM work_groups g WHERE l.book_id = g.id AND g.id <> g.work_id;
+UPDATE activity_events a SET book_id = g.work_id FROM work_groups g WHERE a.book_id = g.id AND g.id <> g.work_id;
+
+-- A list keeps the first of the rows of a work it holds
+CREATE TEMPORARY TABLE merged_book_list_items AS
+SELECT i.*
+FROM book_list_items i
+INNER JOIN work_groups g ON g.id = i.book_id
+WHERE EXISTS (
+    SELECT 1 FROM book_list_items o
+    INNER JOIN work_groups go ON go.id = o.book_id
+    WHERE o.list_id = i.list_id AND go.work_id = g.work_id
+        AND (o.position < i.position OR (o.position = i.position AND o.book_id < i.book_id))
+);
+
+DELETE FROM book_list_items i USING merged_book_list_items m WHERE i.list_id = m.list_id AND i.book_id = m.book_id;
+
+UPDATE book_list_items i SET book_id = g.work_id FROM work_groups g WHERE i.book_id = g.id AND g.id <> g.work_id;
+
+-- Favorites name a book by title, so those of a merged row's title are
+-- renamed to the work's, once per user and work
+CREATE TEMPORARY TABLE merged_favorites AS
+SELECT DISTINCT ON (user_id, work_title) id, book_name, work_title
+FROM (
+    SELECT DISTINCT ON (f.id) f.id, f.user_id, f.book_name, w.title AS work_title
+    FROM user_favorite_books f
+    INNER JOIN books d ON d.title = f.book_name
+    INNER JOIN work_groups g ON g.id = d.id AND g.id <> g.work_id
+    INNER JOIN books w ON w.id = g.work_id
+    WHERE d.title <> w.title
+    ORDER BY f.id, w.id
+) renamed
+WHERE NOT EXISTS (SELECT 1 FROM user_favorite_books o WHERE o.user_id = renamed.user_id AND o.book_name = renamed.work_title)
+ORDER BY user_id, work_title, id;
+
+UPDATE user_favorite_books f SET book_name = m.work_title FROM merged_favorites m WHERE f.id = m.id;
+
+UPDATE slug_redirects r SET target_id = g.work_id
+FROM work_groups g
+WHERE r.entity = 'books' AND r.target_id = g.id AND g.id <> g.work_id;
+
+INSERT INTO slug_redirects (entity, slug, target_id)
+SELECT 'books', d.slug, g.work_id
+FROM work_groups g
+INNER JOIN books d ON d.id = g.id
+WHERE g.id <> g.work_id AND d.slug IS NOT NULL
+ON CONFLICT (entity, slug) DO UPDATE SET target_id = EXCLUDED.target_id;
+
+-- Rankings are rebuilt by the ranking job
+DELETE FROM book_rankings;
+
+DELETE FROM books b USING merged_books m WHERE b.id = m.id;
+
+DROP TABLE merged_favorites;
+DROP TABLE merged_book_list_items;
+DROP TABLE merged_ratings;
+DROP TABLE merged_books;
+DROP TABLE work_groups;
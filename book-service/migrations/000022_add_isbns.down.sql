@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS editions_asin_idx;
+DROP INDEX IF EXISTS editions_isbn13_idx;
+
+ALTER TABLE editions
+    DROP COLUMN IF EXISTS asin,
+    DROP COLUMN IF EXISTS isbn10;
+ALTER TABLE editions RENAME COLUMN isbn13 TO isbn;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS asin,
+    DROP COLUMN IF EXISTS isbn13,
+    DROP COLUMN IF EXISTS isbn10;
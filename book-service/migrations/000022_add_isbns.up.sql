@@ -0,0 +1,35 @@
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS isbn10 text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS isbn13 text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS asin text NOT NULL DEFAULT '';
+
+ALTER TABLE editions RENAME COLUMN isbn TO isbn13;
+ALTER TABLE editions
+    ADD COLUMN IF NOT EXISTS isbn10 text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS asin text NOT NULL DEFAULT '';
+
+-- Same rules as isbn.FromAmazonURL: the ASIN follows /dp/ in the product URL
+-- and is the ISBN-10 for printed books.
+UPDATE editions
+SET asin = COALESCE(substring(url from '/(?:dp|gp/product|gp/aw/d)/([A-Z0-9]{10})(?:[/?]|$)'), '')
+WHERE asin = '';
+
+UPDATE editions
+SET isbn10 = asin
+WHERE isbn10 = '' AND asin ~ '^[0-9]{9}[0-9X]$'
+    AND (SELECT sum((11 - i) * CASE WHEN substr(asin, i, 1) = 'X' THEN 10 ELSE substr(asin, i, 1)::int END)
+         FROM generate_series(1, 10) i) % 11 = 0;
+
+UPDATE editions
+SET isbn13 = '978' || left(isbn10, 9) || ((10 - (
+        SELECT sum(CASE WHEN i % 2 = 0 THEN 3 ELSE 1 END * substr('978' || left(isbn10, 9), i, 1)::int)
+        FROM generate_series(1, 12) i) % 10) % 10)::text
+WHERE isbn10 <> '' AND isbn13 = '';
+
+UPDATE books b
+SET isbn10 = e.isbn10, isbn13 = e.isbn13, asin = e.asin
+FROM editions e
+WHERE e.book_id = b.id AND e.is_primary;
+
+CREATE INDEX IF NOT EXISTS editions_isbn13_idx ON editions(isbn13) WHERE isbn13 <> '';
+CREATE INDEX IF NOT EXISTS editions_asin_idx ON editions(asin) WHERE asin <> '';
@@ -0,0 +1,5 @@
+ALTER TABLE user_favorite_books
+    DROP COLUMN IF EXISTS price_alert_triggered_at,
+    DROP COLUMN IF EXISTS price_alert_below;
+
+DROP TABLE IF EXISTS book_prices;
@@ -0,0 +1,31 @@
+-- One row per observed price of an edition. Prices are free text such as
+-- "₹169.00", amount is the numeric part used for charts and alerts.
+CREATE TABLE IF NOT EXISTS book_prices (
+    id bigserial PRIMARY KEY,
+    book_id bigint NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    edition_id bigint REFERENCES editions(id) ON DELETE SET NULL,
+    edition_type text NOT NULL DEFAULT '',
+    price text NOT NULL,
+    amount numeric(12, 2) GENERATED ALWAYS AS (
+        CASE WHEN regexp_replace(price, '[^0-9.]', '', 'g') ~ '^[0-9]{1,10}(\.[0-9]+)?$'
+            THEN regexp_replace(price, '[^0-9.]', '', 'g')::numeric
+        END
+    ) STORED,
+    source text NOT NULL,
+    recorded_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS book_prices_book_id_idx ON book_prices(book_id, recorded_at);
+CREATE INDEX IF NOT EXISTS book_prices_edition_id_idx ON book_prices(edition_id, recorded_at DESC);
+
+-- The current prices are the first point of every series
+INSERT INTO book_prices (book_id, edition_id, edition_type, price, source, recorded_at)
+SELECT book_id, id, type, price, 'import', created_at
+FROM editions
+WHERE price <> '';
+
+-- Favorites can alert their owner once the book drops to a price. The alert
+-- re-arms when the price goes back above the threshold.
+ALTER TABLE user_favorite_books
+    ADD COLUMN IF NOT EXISTS price_alert_below numeric(12, 2),
+    ADD COLUMN IF NOT EXISTS price_alert_triggered_at timestamp(0) with time zone;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS author_follows;
+DROP TABLE IF EXISTS comment_likes;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,34 @@
+-- Replies to comments
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS parent_id bigint REFERENCES comments(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS comments_parent_id_idx ON comments(parent_id);
+
+CREATE TABLE IF NOT EXISTS comment_likes (
+    comment_id bigint NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (comment_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS author_follows (
+    user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    author_id bigint NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, author_id)
+);
+
+CREATE INDEX IF NOT EXISTS author_follows_author_id_idx ON author_follows(author_id);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type text NOT NULL,
+    actor_id bigint REFERENCES users(id) ON DELETE SET NULL,
+    book_id bigint REFERENCES books(id) ON DELETE CASCADE,
+    subject_id bigint NOT NULL,
+    message text NOT NULL,
+    read_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS notifications_user_id_created_at_idx ON notifications(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS notifications_unread_idx ON notifications(user_id) WHERE read_at IS NULL;
@@ -0,0 +1,8 @@
+-- user_preferences and global_preferences predate this migration, as model.py
+-- and the recommendation service already used them, so only what it added is
+-- removed
+DROP INDEX IF EXISTS global_preferences_category_value_idx;
+ALTER TABLE IF EXISTS global_preferences DROP COLUMN IF EXISTS updated_at, DROP COLUMN IF EXISTS user_count;
+
+DROP INDEX IF EXISTS user_preferences_user_id_category_value_idx;
+ALTER TABLE IF EXISTS user_preferences DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,24 @@
+-- Preferences learned by the recommendation service. Weights are between 0
+-- and 1; global averages are kept on the 0-5 scale the service reads them in.
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category text NOT NULL,
+    value text NOT NULL,
+    weight double precision NOT NULL,
+    count integer NOT NULL DEFAULT 0
+);
+
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW();
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_preferences_user_id_category_value_idx ON user_preferences(user_id, category, value);
+
+CREATE TABLE IF NOT EXISTS global_preferences (
+    category text NOT NULL,
+    value text NOT NULL,
+    avg_weight double precision NOT NULL
+);
+
+ALTER TABLE global_preferences ADD COLUMN IF NOT EXISTS user_count integer NOT NULL DEFAULT 0;
+ALTER TABLE global_preferences ADD COLUMN IF NOT EXISTS updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW();
+
+CREATE UNIQUE INDEX IF NOT EXISTS global_preferences_category_value_idx ON global_preferences(category, value);
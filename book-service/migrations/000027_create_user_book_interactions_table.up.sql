@@ -0,0 +1,12 @@
+-- Interaction events the recommendation service learns preferences from
+CREATE TABLE IF NOT EXISTS user_book_interactions (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    book_id bigint NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    interaction_type text NOT NULL,
+    rating real,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS user_book_interactions_user_id_created_at_idx ON user_book_interactions(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS user_book_interactions_created_at_idx ON user_book_interactions(created_at);
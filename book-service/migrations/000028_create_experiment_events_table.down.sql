@@ -0,0 +1 @@
+DROP TABLE IF EXISTS experiment_events;
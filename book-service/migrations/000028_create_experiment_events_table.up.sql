@@ -0,0 +1,14 @@
+-- Impressions and clicks of recommendation experiments, per variant
+CREATE TABLE IF NOT EXISTS experiment_events (
+    id bigserial PRIMARY KEY,
+    experiment text NOT NULL,
+    variant text NOT NULL,
+    user_id bigint NOT NULL,
+    book_id bigint NOT NULL,
+    event_type text NOT NULL CHECK (event_type IN ('impression', 'click')),
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS experiment_events_experiment_variant_idx ON experiment_events(experiment, variant);
+-- Clicks are matched to the impressions of the same user and book
+CREATE INDEX IF NOT EXISTS experiment_events_user_book_idx ON experiment_events(experiment, user_id, book_id);
@@ -111,3 +111,32 @@ export interface ProfileUpdateData {
   email?: string;
   password?: string;
 }
+
+export interface RecommendationReason {
+  code: string;
+  message: string;
+}
+
+export interface Recommendation {
+  book_id: number;
+  title: string;
+  author: string;
+  main_genre: string;
+  sub_genre: string;
+  type: string;
+  rating: number;
+  ratings_count: number;
+  score: number;
+  reasons: RecommendationReason[];
+}
+
+export interface ExperimentAssignment {
+  experiment: string;
+  variant: string;
+}
+
+export interface RecommendationResponse {
+  recommended_titles: string[];
+  recommendations: Recommendation[];
+  experiment?: ExperimentAssignment;
+}
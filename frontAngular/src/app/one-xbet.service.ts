@@ -1,6 +1,6 @@
 import { Injectable } from '@angular/core';
 import {HttpClient, HttpHeaders} from "@angular/common/http";
-import {User,Book,Genre,SubGenre,Comment,Rating,Metadata,BookFilters, requestBookDetail, FavoriteBook, AuthenticationResponse} from "./models";
+import {User,Book,Genre,SubGenre,Comment,Rating,Metadata,BookFilters, requestBookDetail, FavoriteBook, AuthenticationResponse, RecommendationResponse, ExperimentAssignment} from "./models";
 import { Observable } from 'rxjs/internal/Observable';
 
 @Injectable({
@@ -147,15 +147,23 @@ export class OneXBetService {
   }
 
 
-  getBookRecommendations(userId: number, bookTitles: string[]): Observable<any> {
+  getBookRecommendations(userId: number, bookTitles: string[]): Observable<RecommendationResponse> {
     const headers = this.getAuthHeaders();
-    // Make sure this URL points to your Go backend
-    return this.client.post<any>('http://4.213.138.144/api/model/recommendations', {
+    // The Go recommendation service returns ids and metadata; the model service only titles
+    return this.client.post<RecommendationResponse>('http://4.213.138.144/api/recommendations/recommendations', {
       user_id: userId,
       user_book_titles: bookTitles
     }, { headers });
   }
 
+  // Counts a click on a recommendation for the user's experiment variant
+  trackRecommendationClick(bookId: number): Observable<{ experiment: ExperimentAssignment }> {
+    const headers = this.getAuthHeaders();
+    return this.client.post<{ experiment: ExperimentAssignment }>(`${this.BACKEND_URL}/recommendations/clicks`, {
+      book_id: bookId
+    }, { headers });
+  }
+
 
 
 }
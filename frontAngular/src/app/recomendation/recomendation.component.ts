@@ -1,23 +1,21 @@
 import { Component, OnInit } from '@angular/core';
 import { CommonModule } from '@angular/common';
 import { FormsModule } from '@angular/forms';
-import { AuthenticationResponse, FavoriteBook } from '../models';
+import { RouterLink } from '@angular/router';
+import { AuthenticationResponse, ExperimentAssignment, FavoriteBook, Recommendation, RecommendationResponse } from '../models';
 import { OneXBetService } from '../one-xbet.service';
 
-interface RecomendationResponse {
-  recommended_titles: string[];
-}
-
 @Component({
   selector: 'app-recomendation',
   standalone: true,
-  imports: [CommonModule, FormsModule],
+  imports: [CommonModule, FormsModule, RouterLink],
   templateUrl: './recomendation.component.html',
   styleUrl: './recomendation.component.css'
 })
 export class RecomendationComponent implements OnInit {
   favoriteBooks: FavoriteBook[] = [];
-  recommendedBooks: string[] = [];
+  recommendedBooks: Recommendation[] = [];
+  experiment: ExperimentAssignment | undefined;
   isLoading: boolean = false;
   errorMessage: string = '';
   userSession: AuthenticationResponse | null = null;
@@ -90,8 +88,9 @@ export class RecomendationComponent implements OnInit {
     }
     // Make the recommendation request
     this.httpService.getBookRecommendations(this.userSession.user.id, bookTitles).subscribe(
-      (response: RecomendationResponse) => {
-        this.recommendedBooks = response.recommended_titles;
+      (response: RecommendationResponse) => {
+        this.recommendedBooks = response.recommendations;
+        this.experiment = response.experiment;
         this.showResults = true;
         this.isLoading = false;
       },
@@ -102,4 +101,13 @@ export class RecomendationComponent implements OnInit {
       }
     );
   }
+
+  trackClick(book: Recommendation): void {
+    if (!this.userSession?.user || !this.experiment) {
+      return;
+    }
+    this.httpService.trackRecommendationClick(book.book_id).subscribe({
+      error: error => console.error('Error tracking recommendation click:', error)
+    });
+  }
 }